@@ -1,6 +1,10 @@
 package app
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/cloudfoundry-community/carousel/audit"
 	"github.com/cloudfoundry-community/carousel/credhub"
 	"github.com/cloudfoundry-community/carousel/state"
 
@@ -18,38 +22,83 @@ type Application struct {
 	selectedID  string
 	expanded    map[string]bool
 	refresh     func() error
+	filters     []state.Filter
+	visible     map[*state.Credential]bool
+	criteria    state.RegenerationCriteria
+	view        treeView
+	timeline    timeline
+	// changed holds the ids and paths of the credentials which changed with
+	// the last refresh
+	changed         map[string]bool
+	refreshInterval time.Duration
+	refreshing      bool
+	// revealedID is the credential whose secret is shown, each reveal is
+	// recorded in auditLog
+	revealedID string
+	auditLog   *audit.Log
+	// query, toggles and searchFilter narrow the tree down further than
+	// filters, searching is true while any of them is set. queryErr is why
+	// query is matched against paths instead of as where expression.
+	query        string
+	queryErr     error
+	toggles      map[rune]bool
+	searchFilter state.Filter
+	searching    bool
 }
 
 type Layout struct {
-	main    *tview.Flex
-	tree    *tview.TreeView
-	details *tview.Flex
+	main     *tview.Flex
+	body     *tview.Flex
+	tree     *tview.TreeView
+	details  *tview.Flex
+	search   *tview.InputField
+	toggles  *tview.TextView
+	timeline *tview.Table
+	status   *tview.TextView
 }
 
-func NewApplication(state state.State, ch credhub.CredHub, refresh func() error) *Application {
+func NewApplication(state state.State, ch credhub.CredHub, refresh func() error,
+	criteria state.RegenerationCriteria, filters ...state.Filter) *Application {
 	return &Application{
 		Application: tview.NewApplication(),
 		state:       state,
 		keyBindings: make(map[tcell.Key]func(), 0),
 		expanded:    make(map[string]bool, 0),
+		toggles:     make(map[rune]bool, 0),
+		timeline:    timeline{months: 6},
+		changed:     make(map[string]bool, 0),
 		credhub:     ch,
 		refresh:     refresh,
+		criteria:    criteria,
+		filters:     filters,
 	}
 }
 
 func (a *Application) Init() *Application {
 	a.layout = &Layout{
-		tree:    a.viewTree(),
-		details: a.viewDetails(),
+		tree:     a.viewTree(),
+		details:  a.viewDetails(),
+		search:   a.viewSearch(),
+		toggles:  a.viewSearchToggles(),
+		timeline: a.viewTimeline(),
+		status:   a.viewStatus(),
 	}
 
-	flex := tview.NewFlex().
-		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
-			AddItem(tview.NewFlex().
+	// the timeline is hidden until toggled
+	a.layout.body = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tview.NewFlex().
+			AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
 				AddItem(a.layout.tree, 0, 1, false).
-				AddItem(a.layout.details, 0, 1, false),
-				0, 5, true),
-			0, 1, false)
+				AddItem(a.layout.search, 1, 1, false).
+				AddItem(a.layout.toggles, 1, 1, false),
+				0, 1, false).
+			AddItem(a.layout.details, 0, 1, false),
+			0, 5, true).
+		AddItem(a.layout.timeline, 0, 0, false)
+
+	flex := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(a.layout.body, 0, 1, false).
+		AddItem(a.layout.status, 1, 1, false)
 
 	a.layout.main = flex
 
@@ -57,10 +106,13 @@ func (a *Application) Init() *Application {
 	a.SetFocus(a.layout.tree)
 	a.EnableMouse(false)
 
-	a.updateTree()
+	a.applySearch()
 	a.actionShowDetails(nil)
+	a.setStatus(fmt.Sprintf("Loaded at %s", a.state.UpdatedAt().Format("15:04:05")))
 
+	a.keyBindings[tcell.KeyCtrlR] = a.actionRefresh
 	a.initGlobalKeyInputCaputreHandler()
+	a.watchState()
 
 	return a
 }
@@ -76,6 +128,9 @@ func (a *Application) nextFocusInputCaptureHandler(p tview.Primitive) func(event
 
 func (a *Application) initGlobalKeyInputCaputreHandler() {
 	a.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if a.handleSearchKey(event) || a.handleViewKey(event) || a.handleTimelineKey(event) {
+			return nil
+		}
 		for k, fn := range a.keyBindings {
 			if event.Key() == k {
 				fn()
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/cloudfoundry-community/carousel/state"
+)
+
+type treeView int
+
+const (
+	// typeView roots the tree at credential types and self-signed CA's
+	typeView treeView = iota
+	// deploymentView roots the tree at the deployments of the director
+	deploymentView
+)
+
+// handleViewKey switches between the type and deployment view on v while
+// the tree has focus, it returns false for any other key.
+func (a *Application) handleViewKey(event *tcell.EventKey) bool {
+	if event.Key() != tcell.KeyRune || event.Rune() != 'v' || a.GetFocus() != a.layout.tree {
+		return false
+	}
+
+	if a.view == typeView {
+		a.view = deploymentView
+	} else {
+		a.view = typeView
+	}
+	a.updateTree()
+	return true
+}
+
+// addDeploymentsToTree adds a node per deployment listing the credential
+// versions it uses, whether these are the latest version and the action
+// pending for their path.
+func (a *Application) addDeploymentsToTree(root *tview.TreeNode, deployments state.Deployments) {
+	for _, d := range deployments {
+		credentials := make(state.Credentials, 0)
+		for _, cred := range d.Versions {
+			if a.isVisible(cred) {
+				credentials = append(credentials, cred)
+			}
+		}
+		if len(credentials) == 0 && (a.searching || len(d.Dangling) == 0) {
+			continue
+		}
+		sort.Slice(credentials, func(i, j int) bool {
+			return credentials[i].Name < credentials[j].Name
+		})
+
+		deploymentNode := tview.NewTreeNode(d.Name).SetReference(d)
+
+		var outdated int
+		for _, cred := range credentials {
+			deploymentNode.AddChild(a.deployedCredentialNode(cred))
+			if !cred.Latest {
+				outdated++
+			}
+		}
+		for _, ref := range d.Dangling {
+			deploymentNode.AddChild(tview.NewTreeNode(fmt.Sprintf("%s@%s (dangling)", ref.Name, ref.ID)).
+				SetReference(d).SetColor(tcell.ColorRed))
+		}
+
+		switch {
+		case len(d.Dangling) != 0:
+			deploymentNode.SetColor(tcell.ColorRed)
+		case outdated != 0:
+			deploymentNode.SetText(fmt.Sprintf("%s (%d outdated)", d.Name, outdated)).
+				SetColor(tcell.ColorDarkGoldenrod)
+		}
+
+		root.AddChild(deploymentNode)
+	}
+}
+
+func (a *Application) deployedCredentialNode(cred *state.Credential) *tview.TreeNode {
+	latest := cred.Path.Versions[0]
+
+	lbl := fmt.Sprintf("%s@%s (latest)", cred.Name, cred.ID)
+	if !cred.Latest {
+		lbl = fmt.Sprintf("%s@%s (outdated, latest %s)", cred.Name, cred.ID, latest.ID)
+	}
+	if action := latest.NextAction(a.criteria); action != state.None && action != state.NoOverwrite {
+		lbl = fmt.Sprintf("%s → %s", lbl, action)
+	}
+
+	if a.changed[cred.Name] {
+		lbl = lbl + " (changed)"
+	}
+
+	node := tview.NewTreeNode(lbl).SetReference(cred)
+	switch {
+	case a.changed[cred.Name]:
+		node.SetColor(tcell.ColorGreen)
+	case !cred.Latest:
+		node.SetColor(tcell.ColorDarkGoldenrod)
+	}
+	return node
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/cloudfoundry-community/carousel/bosh"
+	"github.com/cloudfoundry-community/carousel/credhub"
+)
+
+var _ = Describe("deployment view", func() {
+	var a *Application
+
+	texts := func(nodes []*tview.TreeNode) []string {
+		out := make([]string, 0, len(nodes))
+		for _, n := range nodes {
+			out = append(out, n.GetText())
+		}
+		return out
+	}
+
+	BeforeEach(func() {
+		t0 := time.Now().Add(-time.Hour)
+		v1 := password("/bosh/cf/admin_password", "admin-v1", t0)
+		v2 := password("/bosh/cf/admin_password", "admin-v2", t0.Add(time.Minute))
+		a = newTestApplication([]*credhub.Credential{v1, v2}, []*bosh.Variable{
+			variable(v1, "cf"),
+			variable(v2, "cf-canary"),
+			{ID: "deleted", Name: "/bosh/cf/admin_password", Deployment: "broken"},
+		})
+		a.view = deploymentView
+		a.updateTree()
+	})
+
+	It("lists the versions used by each deployment", func() {
+		deployments := a.layout.tree.GetRoot().GetChildren()
+		Expect(texts(deployments)).To(Equal([]string{"broken", "cf (1 outdated)", "cf-canary"}))
+
+		Expect(texts(deployments[1].GetChildren())).To(ConsistOf(
+			HavePrefix("/bosh/cf/admin_password@admin-v1 (outdated, latest admin-v2)")))
+		Expect(deployments[1].GetColor()).To(Equal(tcell.ColorDarkGoldenrod))
+		Expect(texts(deployments[2].GetChildren())).To(ConsistOf(
+			HavePrefix("/bosh/cf/admin_password@admin-v2 (latest)")))
+	})
+
+	It("lists dangling references in red", func() {
+		broken := a.layout.tree.GetRoot().GetChildren()[0]
+		Expect(broken.GetColor()).To(Equal(tcell.ColorRed))
+		Expect(texts(broken.GetChildren())).To(Equal([]string{"/bosh/cf/admin_password@deleted (dangling)"}))
+	})
+})
@@ -19,6 +19,8 @@ func (a *Application) viewDetails() *tview.Flex {
 }
 
 func (a *Application) actionShowDetails(ref interface{}) {
+	// only the details of a credential bind revealing its secret
+	delete(a.keyBindings, tcell.KeyCtrlV)
 	a.layout.details.Clear().AddItem(a.renderDetailsFor(ref), 0, 1, false)
 }
 
@@ -52,7 +54,7 @@ func (a *Application) actionRegenerate(cred *state.Credential) {
 }
 
 func (a *Application) actionRefresh() {
-	a.refreshWithStatusModal()
+	a.refreshInBackground()
 }
 
 func (a *Application) renderDetailsFor(ref interface{}) tview.Primitive {
@@ -61,6 +63,8 @@ func (a *Application) renderDetailsFor(ref interface{}) tview.Primitive {
 		return a.renderPathDetail(v)
 	case *state.Credential:
 		return a.renderCredentialDetail(v)
+	case *state.Deployment:
+		return a.renderDeploymentDetail(v)
 	default:
 		return a.renderWelcome()
 	}
@@ -72,6 +76,9 @@ func (a *Application) renderPathDetail(p *state.Path) tview.Primitive {
 	t.SetTitle("Credhub & BOSH")
 
 	addSimpleRow(t, "Name", p.Name)
+	if phase := p.Phase(); phase != state.PhaseIdle {
+		addSimpleRow(t, "Rotation Phase", phase.String())
+	}
 
 	variableDef, err := yaml.Marshal(p.VariableDefinition)
 	if err != nil {
@@ -89,7 +96,7 @@ func (a *Application) renderPathDetail(p *state.Path) tview.Primitive {
 
 	return tview.NewFlex().
 		SetDirection(tview.FlexRow).
-		AddItem(t, 3, 1, false).
+		AddItem(t, t.GetRowCount()+2, 1, false).
 		AddItem(a.renderPathActions(p), 1, 1, false).
 		AddItem(info, 0, 1, true)
 }
@@ -116,8 +123,9 @@ func (a *Application) renderCredentialDetail(cred *state.Credential) tview.Primi
 		addSimpleRow(t, "Self Signed", strconv.FormatBool(cred.SelfSigned))
 		addSimpleRow(t, "Referenced by leafs", renderCredentials(cred.ReferencedBy))
 		addSimpleRow(t, "Referenced CA's", renderCredentials(cred.References))
+		addSimpleRow(t, "Issuer", renderIssuer(cred))
 
-		detailRows = detailRows + 6
+		detailRows = detailRows + 7
 
 		i, err := certinfo.CertificateText(cred.Certificate)
 		if err != nil {
@@ -129,10 +137,8 @@ func (a *Application) renderCredentialDetail(cred *state.Credential) tview.Primi
 		info.SetBorder(true)
 		info.SetTitle("Raw Certificate")
 	default:
-		info = tview.NewTextView().SetText("TODO").
-			SetTextColor(tcell.Color102)
-		info.SetBorder(true)
-		info.SetTitle("Info")
+		detailRows = detailRows + addSecretRows(t, cred)
+		info = a.renderSecretInfo(cred)
 	}
 
 	a.layout.tree.SetInputCapture(a.nextFocusInputCaptureHandler(t))
@@ -146,12 +152,35 @@ func (a *Application) renderCredentialDetail(cred *state.Credential) tview.Primi
 		AddItem(info, 0, 1, true)
 }
 
+func (a *Application) renderDeploymentDetail(d *state.Deployment) tview.Primitive {
+	t := tview.NewTable()
+	t.SetBorder(true)
+	t.SetTitle("BOSH")
+
+	addSimpleRow(t, "Name", d.Name)
+	addSimpleRow(t, "Credentials", strconv.Itoa(len(d.Versions)))
+	for _, ref := range d.Dangling {
+		addSimpleRow(t, "Dangling", fmt.Sprintf("[red]%s@%s[white] (run bosh deploy to converge)",
+			ref.Name, ref.ID))
+	}
+
+	a.layout.tree.SetInputCapture(a.nextFocusInputCaptureHandler(t))
+	t.SetInputCapture(a.nextFocusInputCaptureHandler(a.layout.tree))
+
+	return tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(t, 0, 1, true)
+}
+
 func (a *Application) renderCredentialActions(cred *state.Credential) tview.Primitive {
 	actions := []string{
 		"Refresh State",
 		"Toggle Transitional",
 		"Delete",
 	}
+	if cred.Type != credhub.Certificate {
+		actions = append(actions, "View Secret")
+	}
 
 	out := []string{}
 	for _, lbl := range actions {
@@ -169,6 +198,12 @@ func (a *Application) renderCredentialActions(cred *state.Credential) tview.Prim
 		a.actionDelete(cred)
 	}
 
+	if cred.Type != credhub.Certificate {
+		a.keyBindings[tcell.KeyCtrlV] = func() {
+			a.actionToggleSecret(cred)
+		}
+	}
+
 	return tview.NewTextView().
 		SetDynamicColors(true).
 		SetText(" " + strings.Join(out, "  "))
@@ -205,6 +240,11 @@ func (a *Application) renderWelcome() tview.Primitive {
 	addSimpleRow(u, "Arrow Up/Down", "navigate the tree / scroll text panel")
 	addSimpleRow(u, "Enter", "expand/collapse tree node")
 	addSimpleRow(u, "Tab", "cycle trough panels")
+	addSimpleRow(u, "/", "search paths by substring or regex, Esc clears")
+	addSimpleRow(u, "e u t p", "toggle expiring, unused, transitional, pending deploy")
+	addSimpleRow(u, "v", "switch between the type and deployment view")
+	addSimpleRow(u, "x", "show/hide the certificate expiry timeline")
+	addSimpleRow(u, "Control+r", "refresh the state in the background")
 	addSimpleRow(u, "Control", "modifier for actions ([yellow]^[white])")
 	addSimpleRow(u, "Control+c", "exit")
 
@@ -229,6 +269,29 @@ func renderDeployments(deployments []*state.Deployment) string {
 	return strings.Join(tmp, ", ")
 }
 
+func renderIssuer(cred *state.Credential) string {
+	switch cred.IssuerStatus {
+	case state.IssuerVerified:
+		chain, complete := cred.Chain()
+		names := make([]string, 0)
+		for _, c := range chain[1:] {
+			names = append(names, c.Name)
+		}
+		if !complete {
+			names = append(names, "?")
+		}
+		return "verified: " + strings.Join(names, " > ")
+	case state.IssuerAmbiguous:
+		return "[yellow]ambiguous[white]: " + renderCredentials(cred.Issuers)
+	case state.IssuerUnverified:
+		return "[red]unverified[white]"
+	case state.IssuerExternal:
+		return "external"
+	default:
+		return ""
+	}
+}
+
 func renderCredentials(credentials state.Credentials) string {
 	tmp := make([]string, 0)
 	for _, c := range credentials {
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/bosh"
+	"github.com/cloudfoundry-community/carousel/credhub"
+	"github.com/cloudfoundry-community/carousel/state"
+)
+
+// newTestApplication returns an initialized application, which is never run,
+// for a director with credentials used by variables.
+func newTestApplication(credentials []*credhub.Credential, variables []*bosh.Variable) *Application {
+	s := state.NewState()
+	Expect(s.Update(credentials, variables)).To(Succeed())
+	return NewApplication(s, nil, func() error { return nil }, state.RegenerationCriteria{}).Init()
+}
+
+func password(name, id string, createdAt time.Time) *credhub.Credential {
+	return &credhub.Credential{
+		ID:               id,
+		Name:             name,
+		Type:             credhub.Password,
+		VersionCreatedAt: &createdAt,
+		Password:         "secret-" + id,
+	}
+}
+
+// certificate returns a self-signed certificate version expiring at expiry,
+// without the x509 certificate itself.
+func certificate(name, id string, createdAt, expiry time.Time) *credhub.Credential {
+	return &credhub.Credential{
+		ID:                   id,
+		Name:                 name,
+		Type:                 credhub.Certificate,
+		VersionCreatedAt:     &createdAt,
+		ExpiryDate:           &expiry,
+		CertificateAuthority: true,
+		SelfSigned:           true,
+	}
+}
+
+func variable(cred *credhub.Credential, deployment string) *bosh.Variable {
+	return &bosh.Variable{ID: cred.ID, Name: cred.Name, Deployment: deployment}
+}
+
+// byID returns the credential version id of the current snapshot of a.
+func byID(a *Application, id string) *state.Credential {
+	cred, found := a.state.Credentials().Find(func(c *state.Credential) bool { return c.ID == id })
+	Expect(found).To(BeTrue(), "credential %s not found", id)
+	return cred
+}
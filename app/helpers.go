@@ -11,7 +11,7 @@ import (
 
 func toStatus(c *state.Credential) string {
 	status := "active"
-	if c.ExpiryDate != nil && c.ExpiryDate.Sub(time.Now()) < time.Hour*24*30 {
+	if c.ExpiryDate != nil && c.ExpiryDate.Sub(time.Now()) < expiringWithin {
 		status = "notice"
 	}
 	if c.VersionCreatedAt.Sub(time.Now()) > time.Hour*24*365 {
@@ -34,11 +34,11 @@ func (a *Application) renderModalAction(body, status string, fn func() error) {
 	modal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 		if buttonLabel == "Continue" {
 			a.statusModal(status)
-			err := fn()
-			if err != nil {
-				a.fatalf("Failed got error: %s", err)
+			if err := fn(); err != nil {
+				a.setStatus(fmt.Sprintf("[red]Failed got error: %s[white]", tview.Escape(err.Error())))
+			} else {
+				a.refreshInBackground()
 			}
-			a.refreshWithStatusModal()
 		}
 		a.renderHome()
 	})
@@ -51,16 +51,6 @@ func (a *Application) renderHome() {
 	a.SetFocus(a.layout.tree)
 }
 
-func (a *Application) refreshWithStatusModal() {
-	a.statusModal("Refreshing State...")
-	err := a.refresh()
-	if err != nil {
-		a.fatalf("Failed to refresh got error: %s", err)
-	}
-	a.updateTree()
-	a.renderHome()
-}
-
 func (a *Application) fatalf(msg string, args ...interface{}) {
 	a.SetRoot(tview.NewBox(), true)
 	a.ForceDraw()
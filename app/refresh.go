@@ -0,0 +1,92 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rivo/tview"
+
+	"github.com/cloudfoundry-community/carousel/state"
+)
+
+// WithAutoRefresh refreshes the state in the background every interval, an
+// interval of 0 only refreshes on request.
+func (a *Application) WithAutoRefresh(interval time.Duration) *Application {
+	a.refreshInterval = interval
+	return a
+}
+
+func (a *Application) viewStatus() *tview.TextView {
+	return tview.NewTextView().SetDynamicColors(true)
+}
+
+// watchState redraws the tree after every refresh of the state, highlighting
+// the credentials which changed, and refreshes on the configured interval.
+func (a *Application) watchState() {
+	events, _ := a.state.Subscribe()
+	go func() {
+		for e := range events {
+			e := e
+			a.QueueUpdateDraw(func() {
+				a.handleStateEvent(e)
+			})
+		}
+	}()
+
+	if a.refreshInterval == 0 {
+		return
+	}
+	go func() {
+		for range time.Tick(a.refreshInterval) {
+			a.QueueUpdateDraw(a.refreshInBackground)
+		}
+	}()
+}
+
+// refreshInBackground refreshes the state without blocking input, unless a
+// refresh is already running. Must be called from the UI goroutine.
+func (a *Application) refreshInBackground() {
+	if a.refreshing {
+		return
+	}
+	a.refreshing = true
+	a.setStatus("[yellow]Refreshing state...[white]")
+
+	go func() {
+		err := a.refresh()
+		a.QueueUpdateDraw(func() {
+			a.refreshing = false
+			if err != nil {
+				a.setStatus(fmt.Sprintf("[red]Refresh failed at %s: %s[white]",
+					time.Now().Format("15:04:05"), tview.Escape(err.Error())))
+			}
+		})
+	}()
+}
+
+func (a *Application) handleStateEvent(e state.Event) {
+	if e.Err != nil {
+		a.setStatus(fmt.Sprintf("[red]Refresh failed at %s: %s[white]",
+			time.Now().Format("15:04:05"), tview.Escape(e.Err.Error())))
+		return
+	}
+
+	changes := state.Compare(state.Record(e.Previous), state.Record(e.Current)).Changes
+	a.changed = make(map[string]bool)
+	for _, c := range changes {
+		a.changed[c.ID] = true
+		a.changed[c.Name] = true
+	}
+
+	a.updateTree()
+	a.setStatus(fmt.Sprintf("Refreshed at %s, %d change(s)",
+		e.Current.UpdatedAt().Format("15:04:05"), len(changes)))
+}
+
+func (a *Application) setStatus(status string) {
+	interval := ""
+	if a.refreshInterval != 0 {
+		interval = fmt.Sprintf(" (auto refresh every %s)", a.refreshInterval)
+	}
+	a.layout.status.SetText(" " + status + interval)
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/bosh"
+	"github.com/cloudfoundry-community/carousel/credhub"
+	"github.com/cloudfoundry-community/carousel/state"
+)
+
+var _ = Describe("handleStateEvent", func() {
+	var (
+		a           *Application
+		credentials []*credhub.Credential
+		variables   []*bosh.Variable
+	)
+
+	BeforeEach(func() {
+		t0 := time.Now().Add(-time.Hour)
+		admin := password("/bosh/cf/admin_password", "admin-v1", t0)
+		other := password("/bosh/cf/other_password", "other-v1", t0)
+		credentials = []*credhub.Credential{admin, other}
+		variables = []*bosh.Variable{variable(admin, "cf"), variable(other, "cf")}
+		a = newTestApplication(credentials, variables)
+	})
+
+	refresh := func() state.Event {
+		previous := a.state.Snapshot()
+		Expect(a.state.Update(credentials, variables)).To(Succeed())
+		return state.Event{Previous: previous, Current: a.state.Snapshot()}
+	}
+
+	It("marks the versions and paths which changed", func() {
+		credentials = append(credentials, password("/bosh/cf/admin_password", "admin-v2", time.Now()))
+		a.handleStateEvent(refresh())
+
+		Expect(a.changed).To(Equal(map[string]bool{
+			"admin-v2": true, "/bosh/cf/admin_password": true,
+		}))
+		Expect(a.layout.status.GetText(true)).To(ContainSubstring("1 change(s)"))
+	})
+
+	It("clears the changes of the previous refresh", func() {
+		credentials = append(credentials, password("/bosh/cf/admin_password", "admin-v2", time.Now()))
+		a.handleStateEvent(refresh())
+		a.handleStateEvent(refresh())
+
+		Expect(a.changed).To(BeEmpty())
+		Expect(a.layout.status.GetText(true)).To(ContainSubstring("0 change(s)"))
+	})
+
+	It("shows the error of a failed refresh and keeps the changes", func() {
+		credentials = append(credentials, password("/bosh/cf/admin_password", "admin-v2", time.Now()))
+		a.handleStateEvent(refresh())
+
+		a.handleStateEvent(state.Event{Err: errors.New("credhub unavailable")})
+		Expect(a.changed).To(HaveKey("admin-v2"))
+		Expect(a.layout.status.GetText(true)).To(ContainSubstring("Refresh failed"))
+		Expect(a.layout.status.GetText(true)).To(ContainSubstring("credhub unavailable"))
+	})
+})
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/cloudfoundry-community/carousel/query"
+	"github.com/cloudfoundry-community/carousel/state"
+)
+
+// expiringWithin is the period in which a certificate is considered to be
+// expiring soon.
+const expiringWithin = time.Hour * 24 * 30
+
+type searchToggle struct {
+	key    rune
+	label  string
+	filter func() state.Filter
+}
+
+var searchToggles = []searchToggle{
+	{'e', "expiring", func() state.Filter {
+		return state.ExpiresBeforeFilter(time.Now().Add(expiringWithin))
+	}},
+	{'u', "unused", state.UnusedFilter},
+	{'t', "transitional", state.TransitionalFilter},
+	{'p', "pending deploy", state.PendingDeployFilter},
+}
+
+func (a *Application) viewSearch() *tview.InputField {
+	search := tview.NewInputField().
+		SetLabel("/").
+		SetFieldBackgroundColor(tcell.ColorDefault).
+		SetPlaceholder("path substring or regex, or ? followed by a where expression")
+
+	search.SetChangedFunc(func(text string) {
+		a.query = text
+		a.applySearch()
+	})
+
+	search.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			search.SetText("")
+		}
+		a.SetFocus(a.layout.tree)
+	})
+
+	return search
+}
+
+func (a *Application) viewSearchToggles() *tview.TextView {
+	return tview.NewTextView().SetDynamicColors(true)
+}
+
+// handleSearchKey focuses the search bar on / and flips the quick toggles
+// while the tree has focus, it returns false for any other key.
+func (a *Application) handleSearchKey(event *tcell.EventKey) bool {
+	if event.Key() != tcell.KeyRune || a.GetFocus() != a.layout.tree {
+		return false
+	}
+
+	if event.Rune() == '/' {
+		a.SetFocus(a.layout.search)
+		return true
+	}
+
+	for _, toggle := range searchToggles {
+		if event.Rune() == toggle.key {
+			a.toggles[toggle.key] = !a.toggles[toggle.key]
+			a.applySearch()
+			return true
+		}
+	}
+	return false
+}
+
+// applySearch compiles the search query and toggles into the search filter
+// and redraws the tree. The query matches paths, unless it starts with ? or
+// compares a field, then it is parsed as where expression.
+func (a *Application) applySearch() {
+	filters := make([]state.Filter, 0)
+
+	hadQueryErr := a.queryErr != nil
+	a.queryErr = nil
+	switch expr, isExpr := whereExpression(a.query); {
+	case isExpr && strings.TrimSpace(expr) == "":
+		// nothing typed after the ? yet
+	case isExpr:
+		filter, err := query.Parse(expr)
+		if err != nil {
+			a.queryErr = err
+			filter = func(*state.Credential) bool { return false }
+		}
+		filters = append(filters, filter)
+	case a.query != "":
+		filters = append(filters, pathSearchFilter(a.query))
+	}
+
+	for _, toggle := range searchToggles {
+		if a.toggles[toggle.key] {
+			filters = append(filters, toggle.filter())
+		}
+	}
+
+	a.searchFilter = state.AndFilter(filters...)
+	a.searching = len(filters) != 0
+	a.layout.toggles.SetText(a.renderSearchToggles())
+	a.updateTree()
+
+	switch {
+	case a.queryErr != nil:
+		a.setStatus(fmt.Sprintf("[yellow]Invalid where expression, %s[white]", tview.Escape(a.queryErr.Error())))
+	case hadQueryErr:
+		a.setStatus("")
+	}
+}
+
+// whereExpression returns the where expression given as search query, either
+// after a leading ? or as query comparing a field.
+func whereExpression(text string) (string, bool) {
+	if strings.HasPrefix(text, "?") {
+		return strings.TrimPrefix(text, "?"), true
+	}
+	return text, query.HasComparison(text)
+}
+
+// pathSearchFilter matches paths against text as case insensitive regex, or
+// as substring while it is not a valid regex (yet).
+func pathSearchFilter(text string) state.Filter {
+	expr, err := regexp.Compile("(?i)" + text)
+	if err != nil {
+		expr = regexp.MustCompile("(?i)" + regexp.QuoteMeta(text))
+	}
+	return state.PathRegexFilter(expr)
+}
+
+func (a *Application) renderSearchToggles() string {
+	out := make([]string, 0)
+	for _, toggle := range searchToggles {
+		color := "white"
+		if a.toggles[toggle.key] {
+			color = "green"
+		}
+		out = append(out, fmt.Sprintf("[yellow]%c[white] [%s]%s[white]", toggle.key, color, toggle.label))
+	}
+	return " " + strings.Join(out, "  ")
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/credhub"
+)
+
+var _ = Describe("applySearch", func() {
+	var a *Application
+
+	BeforeEach(func() {
+		now := time.Now()
+		a = newTestApplication([]*credhub.Credential{
+			password("/bosh/cf/admin_password", "admin-v1", now),
+			certificate("/bosh/cf/diego_ca", "diego-v1", now, now.Add(time.Hour)),
+		}, nil)
+	})
+
+	search := func(query string) {
+		a.query = query
+		a.applySearch()
+	}
+
+	It("matches paths by default", func() {
+		for _, text := range []string{"DIEGO", "diego_ca", "ca", "cf/d"} {
+			search(text)
+			Expect(a.queryErr).ToNot(HaveOccurred())
+			Expect(a.layout.status.GetText(true)).ToNot(ContainSubstring("where expression"))
+			Expect(a.searchFilter(byID(a, "diego-v1"))).To(BeTrue(), text)
+			Expect(a.searchFilter(byID(a, "admin-v1"))).To(BeFalse(), text)
+		}
+	})
+
+	It("parses queries comparing a field as where expression", func() {
+		search("type = certificate")
+		Expect(a.queryErr).ToNot(HaveOccurred())
+		Expect(a.searchFilter(byID(a, "diego-v1"))).To(BeTrue())
+		Expect(a.searchFilter(byID(a, "admin-v1"))).To(BeFalse())
+	})
+
+	It("parses queries starting with ? as where expression", func() {
+		search("?not ca")
+		Expect(a.queryErr).ToNot(HaveOccurred())
+		Expect(a.searchFilter(byID(a, "diego-v1"))).To(BeFalse())
+		Expect(a.searchFilter(byID(a, "admin-v1"))).To(BeTrue())
+	})
+
+	It("shows why a where expression does not parse until it does", func() {
+		search("?colour")
+		Expect(a.queryErr).To(MatchError(ContainSubstring("unknown field")))
+		Expect(a.layout.status.GetText(true)).To(ContainSubstring("unknown field"))
+		Expect(a.searchFilter(byID(a, "diego-v1"))).To(BeFalse())
+
+		search("?ca")
+		Expect(a.queryErr).ToNot(HaveOccurred())
+		Expect(a.layout.status.GetText(true)).ToNot(ContainSubstring("unknown field"))
+		Expect(a.searchFilter(byID(a, "diego-v1"))).To(BeTrue())
+	})
+})
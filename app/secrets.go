@@ -0,0 +1,256 @@
+package app
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/cloudfoundry-community/carousel/audit"
+	"github.com/cloudfoundry-community/carousel/credhub"
+	"github.com/cloudfoundry-community/carousel/state"
+)
+
+// WithAuditLog records every revealed secret in log, without an audit log
+// secrets can not be revealed.
+func (a *Application) WithAuditLog(log *audit.Log) *Application {
+	a.auditLog = log
+	return a
+}
+
+// addSecretRows adds the non secret properties of password, user, ssh, rsa,
+// json and value credentials to t and returns the number of rows added.
+func addSecretRows(t *tview.Table, cred *state.Credential) int {
+	rows := t.GetRowCount()
+
+	switch cred.Type {
+	case credhub.Password:
+		addSimpleRow(t, "Length", strconv.Itoa(len(cred.Password)))
+	case credhub.User:
+		addSimpleRow(t, "Username", cred.Username)
+		addSimpleRow(t, "Password Length", strconv.Itoa(len(cred.Password)))
+	case credhub.SSH, credhub.RSA:
+		addSimpleRow(t, "Key", describePublicKey(cred))
+		addSimpleRow(t, "Fingerprint", publicKeyFingerprint(cred))
+	case credhub.JSON:
+		addSimpleRow(t, "Keys", strconv.Itoa(len(cred.JSON)))
+	case credhub.Value:
+		addSimpleRow(t, "Length", strconv.Itoa(len(cred.Value)))
+	}
+
+	if cred.Path.VariableDefinition != nil {
+		addSimpleRow(t, "Generation", renderOptions(cred.Path.VariableDefinition.Options))
+	}
+
+	return t.GetRowCount() - rows
+}
+
+// renderSecretInfo shows the public parts of cred, and its secret value once
+// revealed.
+func (a *Application) renderSecretInfo(cred *state.Credential) *tview.TextView {
+	var b strings.Builder
+
+	switch cred.Type {
+	case credhub.SSH, credhub.RSA:
+		fmt.Fprintf(&b, "public key:\n%s\n\n", tview.Escape(strings.TrimSpace(cred.PublicKey)))
+	case credhub.JSON:
+		fmt.Fprintf(&b, "structure:\n%s\n\n", tview.Escape(renderJSONStructure(cred.JSON, "  ")))
+	}
+
+	if a.revealedID == cred.ID {
+		fmt.Fprintf(&b, "[red]secret:[white]\n%s\n", tview.Escape(secretValue(cred)))
+	} else {
+		b.WriteString("secret: [yellow]^V[white] to reveal\n")
+	}
+
+	info := tview.NewTextView().SetDynamicColors(true).SetText(b.String()).
+		SetTextColor(tcell.Color102)
+	info.SetBorder(true)
+	info.SetTitle("Info")
+	return info
+}
+
+// actionToggleSecret hides the revealed secret of cred, or reveals it after
+// confirmation and recording the reveal in the audit log.
+func (a *Application) actionToggleSecret(cred *state.Credential) {
+	if a.revealedID == cred.ID {
+		a.revealedID = ""
+		a.actionShowDetails(cred)
+		return
+	}
+
+	if a.auditLog == nil {
+		a.setStatus("[red]Revealing secrets requires an audit log, see --audit-log[white]")
+		return
+	}
+
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("Reveal the secret value of %s@%s?\nThe reveal is recorded in the audit log",
+			cred.Name, cred.ID)).
+		AddButtons([]string{"Reveal", "Cancel"})
+	modal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		if buttonLabel == "Reveal" {
+			a.revealSecret(cred)
+		}
+		a.renderHome()
+	})
+
+	a.SetRoot(modal, true)
+}
+
+// revealSecret records the reveal of cred in the audit log and shows its
+// secret, unless recording failed.
+func (a *Application) revealSecret(cred *state.Credential) {
+	err := a.auditLog.Append(audit.Entry{
+		Action:        "reveal",
+		Path:          cred.Name,
+		VersionBefore: cred.ID,
+		Result:        audit.Success,
+	})
+	if err != nil {
+		a.setStatus(fmt.Sprintf("[red]Not revealing, %s[white]", tview.Escape(err.Error())))
+		return
+	}
+	a.revealedID = cred.ID
+	a.actionShowDetails(cred)
+}
+
+func secretValue(cred *state.Credential) string {
+	switch cred.Type {
+	case credhub.Password, credhub.User:
+		return cred.Password
+	case credhub.SSH, credhub.RSA:
+		return cred.PrivateKey
+	case credhub.JSON:
+		data, err := json.MarshalIndent(cred.JSON, "", "  ")
+		if err != nil {
+			return err.Error()
+		}
+		return string(data)
+	default:
+		return cred.Value
+	}
+}
+
+// describePublicKey returns the algorithm and size of the public key of an
+// ssh or rsa credential.
+func describePublicKey(cred *state.Credential) string {
+	key, err := cryptoPublicKey(cred)
+	if err != nil {
+		return fmt.Sprintf("[red]%s[white]", err)
+	}
+	if rsaKey, ok := key.(*rsa.PublicKey); ok {
+		return fmt.Sprintf("RSA %d bits", rsaKey.N.BitLen())
+	}
+	return fmt.Sprintf("%T", key)
+}
+
+// publicKeyFingerprint returns the SHA256 fingerprint CredHub computed for
+// ssh credentials, and computes it for rsa credentials.
+func publicKeyFingerprint(cred *state.Credential) string {
+	if cred.PublicKeyFingerprint != "" {
+		return "SHA256:" + cred.PublicKeyFingerprint
+	}
+	if cred.Type == credhub.SSH {
+		if key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(cred.PublicKey)); err == nil {
+			return ssh.FingerprintSHA256(key)
+		}
+		return ""
+	}
+	block, _ := pem.Decode([]byte(cred.PublicKey))
+	if block == nil {
+		return ""
+	}
+	sum := sha256.Sum256(block.Bytes)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+func cryptoPublicKey(cred *state.Credential) (interface{}, error) {
+	if cred.Type == credhub.SSH {
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(cred.PublicKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %s", err)
+		}
+		cryptoKey, ok := key.(ssh.CryptoPublicKey)
+		if !ok {
+			return nil, fmt.Errorf("unsupported public key: %s", key.Type())
+		}
+		return cryptoKey.CryptoPublicKey(), nil
+	}
+
+	block, _ := pem.Decode([]byte(cred.PublicKey))
+	if block == nil {
+		return nil, fmt.Errorf("invalid public key: no PEM data")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %s", err)
+	}
+	return key, nil
+}
+
+// renderJSONStructure lists the keys of a JSON credential with the types of
+// their values, without the values themselves.
+func renderJSONStructure(value interface{}, indent string) string {
+	lines := make([]string, 0)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			lines = append(lines, fmt.Sprintf("%s%s: %s", indent, k, jsonType(v[k])))
+			if nested := renderJSONStructure(v[k], indent+"  "); nested != "" {
+				lines = append(lines, nested)
+			}
+		}
+	case []interface{}:
+		if len(v) != 0 {
+			lines = append(lines, fmt.Sprintf("%s[]: %s", indent, jsonType(v[0])))
+			if nested := renderJSONStructure(v[0], indent+"  "); nested != "" {
+				lines = append(lines, nested)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return fmt.Sprintf("array (%d)", len(v))
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "bool"
+	default:
+		return "null"
+	}
+}
+
+// renderOptions renders the generation options of a variable definition,
+// e.g. length=40, exclude_upper=true.
+func renderOptions(options map[string]interface{}) string {
+	out := make([]string, 0, len(options))
+	for k, v := range options {
+		out = append(out, fmt.Sprintf("%s=%v", k, v))
+	}
+	sort.Strings(out)
+	return strings.Join(out, ", ")
+}
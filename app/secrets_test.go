@@ -0,0 +1,86 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/cloudfoundry-community/carousel/audit"
+	"github.com/cloudfoundry-community/carousel/bosh"
+	"github.com/cloudfoundry-community/carousel/credhub"
+)
+
+var _ = Describe("secrets", func() {
+	var (
+		a       *Application
+		dir     string
+		logPath string
+		log     *audit.Log
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "app-")
+		Expect(err).ToNot(HaveOccurred())
+		logPath = filepath.Join(dir, "audit.jsonl")
+		log, err = audit.Open(logPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		admin := password("/bosh/cf/admin_password", "admin-v1", time.Now())
+		a = newTestApplication([]*credhub.Credential{admin}, []*bosh.Variable{variable(admin, "cf")}).
+			WithAuditLog(log)
+	})
+
+	AfterEach(func() {
+		log.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("records each reveal in the audit log", func() {
+		a.revealSecret(byID(a, "admin-v1"))
+		Expect(a.revealedID).To(Equal("admin-v1"))
+
+		data, err := ioutil.ReadFile(logPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring(`"action":"reveal"`))
+		Expect(string(data)).To(ContainSubstring(`"path":"/bosh/cf/admin_password"`))
+		Expect(string(data)).To(ContainSubstring(`"version_before":"admin-v1"`))
+		Expect(string(data)).ToNot(ContainSubstring("secret-admin-v1"))
+	})
+
+	It("does not reveal when the reveal can not be recorded", func() {
+		log.Close()
+		a.revealSecret(byID(a, "admin-v1"))
+		Expect(a.revealedID).To(BeEmpty())
+		Expect(a.layout.status.GetText(true)).To(ContainSubstring("Not revealing"))
+	})
+
+	It("requires an audit log", func() {
+		a.WithAuditLog(nil).actionToggleSecret(byID(a, "admin-v1"))
+		Expect(a.revealedID).To(BeEmpty())
+		Expect(a.layout.status.GetText(true)).To(ContainSubstring("requires an audit log"))
+	})
+
+	It("hides the secret when moving the selection", func() {
+		screen := tcell.NewSimulationScreen("")
+		Expect(screen.Init()).To(Succeed())
+		screen.SetSize(80, 25)
+		tree := a.layout.tree
+		tree.SetRect(0, 0, 80, 25)
+		tree.Draw(screen)
+
+		a.revealSecret(byID(a, "admin-v1"))
+		Expect(a.revealedID).To(Equal("admin-v1"))
+
+		tree.InputHandler()(tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone), func(p tview.Primitive) {})
+		tree.Draw(screen)
+		Expect(a.revealedID).To(BeEmpty())
+	})
+})
@@ -0,0 +1,291 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/cloudfoundry-community/carousel/credhub"
+	"github.com/cloudfoundry-community/carousel/state"
+)
+
+const week = time.Hour * 24 * 7
+
+// timeline plots the expiry dates of certificates for the next months,
+// grouped by the deployments using them or by the CA signing them.
+type timeline struct {
+	shown  bool
+	months int
+	byCA   bool
+}
+
+type timelineGroup struct {
+	name        string
+	credentials state.Credentials
+}
+
+func (a *Application) viewTimeline() *tview.Table {
+	t := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	t.SetBorder(true)
+
+	t.SetSelectedFunc(func(row, column int) {
+		if cred, ok := t.GetCell(row, 0).GetReference().(*state.Credential); ok {
+			a.jumpTo(cred)
+		}
+	})
+
+	t.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape, event.Key() == tcell.KeyRune && event.Rune() == 'x':
+			a.toggleTimeline()
+		case event.Key() == tcell.KeyTab:
+			a.SetFocus(a.layout.tree)
+		case event.Key() != tcell.KeyRune:
+			return event
+		case event.Rune() == 'g':
+			a.timeline.byCA = !a.timeline.byCA
+			a.updateTimeline()
+		case event.Rune() == '+' && a.timeline.months < 24:
+			a.timeline.months++
+			a.updateTimeline()
+		case event.Rune() == '-' && a.timeline.months > 1:
+			a.timeline.months--
+			a.updateTimeline()
+		default:
+			return event
+		}
+		return nil
+	})
+
+	return t
+}
+
+// handleTimelineKey shows the timeline on x while the tree has focus, it
+// returns false for any other key.
+func (a *Application) handleTimelineKey(event *tcell.EventKey) bool {
+	if event.Key() != tcell.KeyRune || event.Rune() != 'x' || a.GetFocus() != a.layout.tree {
+		return false
+	}
+	a.toggleTimeline()
+	return true
+}
+
+func (a *Application) toggleTimeline() {
+	a.timeline.shown = !a.timeline.shown
+	if a.timeline.shown {
+		a.layout.body.ResizeItem(a.layout.timeline, 0, 2)
+		a.updateTimeline()
+		a.SetFocus(a.layout.timeline)
+		return
+	}
+	a.layout.body.ResizeItem(a.layout.timeline, 0, 0)
+	a.SetFocus(a.layout.tree)
+}
+
+func (a *Application) updateTimeline() {
+	if !a.timeline.shown {
+		return
+	}
+
+	now := time.Now()
+	horizon := now.AddDate(0, a.timeline.months, 0)
+	weeks := int(horizon.Sub(now)/week) + 1
+
+	t := a.layout.timeline.Clear()
+	grouping := "deployment"
+	if a.timeline.byCA {
+		grouping = "CA"
+	}
+	t.SetTitle(fmt.Sprintf("Expiry in the next %d months by %s ([yellow]g[white] group, "+
+		"[yellow]+/-[white] months, [yellow]Enter[white] jump to, [yellow]x[white] close)",
+		a.timeline.months, grouping))
+	t.SetTitleColor(tcell.ColorWhite)
+
+	t.SetCell(0, 0, tview.NewTableCell("").SetSelectable(false))
+	t.SetCell(0, 1, tview.NewTableCell("").SetSelectable(false))
+	t.SetCell(0, 2, tview.NewTableCell(renderMonths(now, weeks)).SetSelectable(false))
+
+	for _, group := range a.timelineGroups(horizon) {
+		row := t.GetRowCount()
+		t.SetCell(row, 0, tview.NewTableCell(group.name).
+			SetStyle(tcell.Style{}.Bold(true)).SetSelectable(false))
+		t.SetCell(row, 1, tview.NewTableCell(fmt.Sprintf("%d", len(group.credentials))).
+			SetSelectable(false))
+		t.SetCell(row, 2, tview.NewTableCell(renderWeekCounts(group.credentials, now, weeks)).
+			SetSelectable(false))
+
+		for _, cred := range group.credentials {
+			row := t.GetRowCount()
+			color := expiryColor(cred, now)
+			t.SetCell(row, 0, tview.NewTableCell("  "+cred.PathVersion()).
+				SetReference(cred).SetTextColor(color))
+			t.SetCell(row, 1, tview.NewTableCell(cred.ExpiryDate.Format("2006-01-02")).
+				SetTextColor(color))
+			t.SetCell(row, 2, tview.NewTableCell(renderExpiry(cred, now, weeks)).
+				SetTextColor(color))
+		}
+	}
+
+	if t.GetRowCount() == 1 {
+		t.SetCell(1, 0, tview.NewTableCell("no certificates expire in this period").SetSelectable(false))
+	}
+}
+
+// timelineGroups returns the latest or used certificates visible in the
+// tree expiring before horizon, grouped by deployment or signing CA.
+func (a *Application) timelineGroups(horizon time.Time) []timelineGroup {
+	credentials := a.state.Snapshot().Credentials(append([]state.Filter{
+		state.TypeFilter(credhub.Certificate),
+		state.OrFilter(state.LatestFilter(), state.ActiveFilter()),
+		state.ExpiresBeforeFilter(horizon),
+		a.searchFilter,
+	}, a.filters...)...)
+	sort.Slice(credentials, func(i, j int) bool {
+		return credentials[i].ExpiryDate.Before(*credentials[j].ExpiryDate)
+	})
+
+	groups := make(map[string]state.Credentials)
+	for _, cred := range credentials {
+		if !a.timeline.byCA {
+			for _, d := range cred.Deployments {
+				groups[d.Name] = append(groups[d.Name], cred)
+			}
+			if len(cred.Deployments) == 0 {
+				groups["(not deployed)"] = append(groups["(not deployed)"], cred)
+			}
+			continue
+		}
+
+		switch {
+		case cred.SignedBy != nil:
+			groups[cred.SignedBy.Name] = append(groups[cred.SignedBy.Name], cred)
+		case cred.SelfSigned:
+			groups["(self-signed)"] = append(groups["(self-signed)"], cred)
+		default:
+			groups["(unknown issuer)"] = append(groups["(unknown issuer)"], cred)
+		}
+	}
+
+	out := make([]timelineGroup, 0, len(groups))
+	for name, credentials := range groups {
+		out = append(out, timelineGroup{name: name, credentials: credentials})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].name < out[j].name
+	})
+	return out
+}
+
+// jumpTo selects cred in the tree, expanding the nodes leading to it. The
+// deployment view only lists deployed versions, so it falls back to the
+// type view for the others.
+func (a *Application) jumpTo(cred *state.Credential) {
+	a.selectedID = cred.ID
+	a.updateTree()
+
+	node, parents := findNode(a.layout.tree.GetRoot(), cred)
+	if node == nil && a.view == deploymentView {
+		a.view = typeView
+		a.updateTree()
+		node, parents = findNode(a.layout.tree.GetRoot(), cred)
+	}
+	if node == nil {
+		return
+	}
+
+	for n := parents[node]; n != nil; n = parents[n] {
+		a.expanded[refToID(n.GetReference())] = true
+		n.SetExpanded(true)
+	}
+	a.layout.tree.SetCurrentNode(node)
+	a.actionShowDetails(cred)
+	a.SetFocus(a.layout.tree)
+}
+
+// findNode returns the first node referencing cred below root together with
+// the parent of each node walked.
+func findNode(root *tview.TreeNode,
+	cred *state.Credential) (*tview.TreeNode, map[*tview.TreeNode]*tview.TreeNode) {
+	var found *tview.TreeNode
+	parents := make(map[*tview.TreeNode]*tview.TreeNode)
+	root.Walk(func(node, parent *tview.TreeNode) bool {
+		parents[node] = parent
+		if found == nil && node.GetReference() == cred {
+			found = node
+		}
+		return found == nil
+	})
+	return found, parents
+}
+
+func expiryColor(cred *state.Credential, now time.Time) tcell.Color {
+	switch {
+	case cred.ExpiryDate.Before(now):
+		return tcell.ColorRed
+	case cred.ExpiryDate.Before(now.Add(expiringWithin)):
+		return tcell.ColorDarkGoldenrod
+	default:
+		return tcell.ColorWhite
+	}
+}
+
+func expiryWeek(cred *state.Credential, now time.Time) int {
+	if cred.ExpiryDate.Before(now) {
+		return 0
+	}
+	return int(cred.ExpiryDate.Sub(now) / week)
+}
+
+// renderMonths labels the week columns in which a month starts.
+func renderMonths(now time.Time, weeks int) string {
+	out := []rune(strings.Repeat(" ", weeks+3))
+	month := now.Month()
+	for i := 0; i < weeks; i++ {
+		start := now.Add(week * time.Duration(i))
+		if start.Month() == month {
+			continue
+		}
+		month = start.Month()
+		copy(out[i:], []rune(start.Format("Jan")))
+	}
+	return string(out)
+}
+
+// renderWeekCounts shows the number of certificates expiring in each week,
+// weeks in which several expire stand out as clusters.
+func renderWeekCounts(credentials state.Credentials, now time.Time, weeks int) string {
+	counts := make([]int, weeks)
+	for _, cred := range credentials {
+		counts[expiryWeek(cred, now)]++
+	}
+
+	var b strings.Builder
+	for _, count := range counts {
+		switch {
+		case count == 0:
+			b.WriteString("·")
+		case count == 1:
+			b.WriteString("1")
+		case count < 10:
+			fmt.Fprintf(&b, "[yellow]%d[white]", count)
+		default:
+			b.WriteString("[yellow]+[white]")
+		}
+	}
+	return b.String()
+}
+
+func renderExpiry(cred *state.Credential, now time.Time, weeks int) string {
+	pos := expiryWeek(cred, now)
+	marker := "●"
+	if cred.ExpiryDate.Before(now) {
+		marker = "◀"
+	}
+	return strings.Repeat("·", pos) + marker + strings.Repeat("·", weeks-pos-1)
+}
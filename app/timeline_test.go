@@ -0,0 +1,110 @@
+package app
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/bosh"
+	"github.com/cloudfoundry-community/carousel/credhub"
+	"github.com/cloudfoundry-community/carousel/state"
+)
+
+var _ = Describe("timeline", func() {
+	var now time.Time
+
+	expiringIn := func(d time.Duration) *state.Credential {
+		return &state.Credential{Credential: certificate("/ca", "ca-v1", now, now.Add(d))}
+	}
+
+	BeforeEach(func() {
+		now = time.Now()
+	})
+
+	Describe("expiryWeek", func() {
+		It("counts whole weeks from now", func() {
+			Expect(expiryWeek(expiringIn(time.Hour), now)).To(Equal(0))
+			Expect(expiryWeek(expiringIn(8*24*time.Hour), now)).To(Equal(1))
+			Expect(expiryWeek(expiringIn(15*24*time.Hour), now)).To(Equal(2))
+		})
+
+		It("puts expired certificates in the first week", func() {
+			Expect(expiryWeek(expiringIn(-30*24*time.Hour), now)).To(Equal(0))
+		})
+	})
+
+	Describe("renderWeekCounts", func() {
+		It("shows the number of certificates expiring per week", func() {
+			credentials := state.Credentials{
+				expiringIn(time.Hour), expiringIn(2 * time.Hour), expiringIn(15 * 24 * time.Hour),
+			}
+			Expect(renderWeekCounts(credentials, now, 4)).To(Equal("[yellow]2[white]·1·"))
+		})
+
+		It("marks weeks with ten or more certificates as +", func() {
+			credentials := make(state.Credentials, 0)
+			for i := 0; i < 10; i++ {
+				credentials = append(credentials, expiringIn(8*24*time.Hour))
+			}
+			Expect(renderWeekCounts(credentials, now, 2)).To(Equal("·[yellow]+[white]"))
+		})
+	})
+
+	Describe("renderExpiry", func() {
+		It("marks the week a certificate expires in", func() {
+			Expect(renderExpiry(expiringIn(15*24*time.Hour), now, 4)).To(Equal("··●·"))
+		})
+
+		It("marks expired certificates at the start", func() {
+			Expect(renderExpiry(expiringIn(-time.Hour), now, 4)).To(Equal("◀···"))
+		})
+	})
+
+	Describe("timelineGroups", func() {
+		var a *Application
+
+		groups := func() map[string][]string {
+			out := make(map[string][]string)
+			for _, g := range a.timelineGroups(now.AddDate(0, 6, 0)) {
+				for _, cred := range g.credentials {
+					out[g.name] = append(out[g.name], cred.ID)
+				}
+			}
+			return out
+		}
+
+		BeforeEach(func() {
+			t0 := now.Add(-time.Hour)
+			soon := certificate("/bosh/cf/ca", "soon", t0, now.Add(10*24*time.Hour))
+			later := certificate("/bosh/cf/router_ca", "later", t0, now.Add(60*24*time.Hour))
+			unused := certificate("/bosh/cf/old_ca", "unused", t0, now.Add(20*24*time.Hour))
+			distant := certificate("/bosh/cf/root_ca", "distant", t0, now.AddDate(2, 0, 0))
+			a = newTestApplication(
+				[]*credhub.Credential{soon, later, unused, distant},
+				[]*bosh.Variable{variable(soon, "cf"), variable(later, "cf"), variable(later, "diego"),
+					variable(distant, "cf")})
+		})
+
+		It("groups the certificates expiring before the horizon by deployment", func() {
+			Expect(groups()).To(Equal(map[string][]string{
+				"(not deployed)": {"unused"},
+				"cf":             {"soon", "later"},
+				"diego":          {"later"},
+			}))
+		})
+
+		It("groups the certificates by signing CA", func() {
+			a.timeline.byCA = true
+			Expect(groups()).To(Equal(map[string][]string{
+				"(self-signed)": {"soon", "unused", "later"},
+			}))
+		})
+
+		It("only includes certificates matching the search", func() {
+			a.query = "deployment = diego"
+			a.applySearch()
+			Expect(groups()).To(Equal(map[string][]string{"cf": {"later"}, "diego": {"later"}}))
+		})
+	})
+})
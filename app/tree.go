@@ -20,30 +20,14 @@ func (a *Application) updateTree() {
 	root := tview.NewTreeNode("∎")
 	root.SetReference("root")
 	a.expanded[refToID(root.GetReference())] = true
-
-	for _, credType := range credhub.CredentialTypeValues() {
-		credentials := a.state.Credentials(
-			state.TypeFilter(credType),
-			state.SelfSignedFilter(),
-			state.LatestFilter())
-
-		if len(credentials) == 0 {
-			continue
-		}
-
-		typeNode := tview.NewTreeNode(credType.String()).SetReference(credType.String())
-
-		if expanded, ok := a.expanded[refToID(typeNode.GetReference())]; ok {
-			typeNode.SetExpanded(expanded)
-		} else {
-			typeNode.SetExpanded(false)
-		}
-
-		root.AddChild(typeNode)
-
-		for _, credential := range credentials {
-			typeNode.SetChildren(append(typeNode.GetChildren(), addToTree(credential.Path.Versions)...))
-		}
+	a.visible = make(map[*state.Credential]bool)
+
+	snapshot := a.state.Snapshot()
+	if a.view == deploymentView {
+		root.SetText("∎ deployments")
+		a.addDeploymentsToTree(root, snapshot.Deployments())
+	} else {
+		a.addTypesToTree(root, snapshot)
 	}
 
 	var currentNode *tview.TreeNode
@@ -61,7 +45,8 @@ func (a *Application) updateTree() {
 		if expanded, ok := a.expanded[refToID(node.GetReference())]; ok {
 			node.SetExpanded(expanded)
 		} else {
-			node.SetExpanded(false)
+			// expand everything found by a search
+			node.SetExpanded(a.searching)
 		}
 
 		return true
@@ -75,6 +60,7 @@ func (a *Application) updateTree() {
 
 	a.layout.tree.SetChangedFunc(func(node *tview.TreeNode) {
 		a.selectedID = refToID(node.GetReference())
+		a.revealedID = ""
 		a.actionShowDetails(node.GetReference())
 	})
 
@@ -82,6 +68,43 @@ func (a *Application) updateTree() {
 		a.expanded[refToID(node.GetReference())] = !node.IsExpanded()
 		node.SetExpanded(!node.IsExpanded())
 	})
+
+	a.updateTimeline()
+}
+
+// addTypesToTree adds a node per credential type listing the paths of its
+// self-signed credentials with the versions they signed.
+func (a *Application) addTypesToTree(root *tview.TreeNode, snapshot state.Snapshot) {
+	for _, credType := range credhub.CredentialTypeValues() {
+		credentials := snapshot.Credentials(
+			state.TypeFilter(credType),
+			state.SelfSignedFilter(),
+			state.LatestFilter())
+
+		if len(credentials) == 0 {
+			continue
+		}
+
+		typeNode := tview.NewTreeNode(credType.String()).SetReference(credType.String())
+
+		if expanded, ok := a.expanded[refToID(typeNode.GetReference())]; ok {
+			typeNode.SetExpanded(expanded)
+		} else {
+			typeNode.SetExpanded(false)
+		}
+
+		for _, credential := range credentials {
+			typeNode.SetChildren(append(typeNode.GetChildren(), a.addToTree(credential.Path.Versions)...))
+		}
+
+		if len(typeNode.GetChildren()) != 0 {
+			root.AddChild(typeNode)
+		}
+	}
+
+	if dangling := snapshot.Deployments().Dangling(); len(dangling) != 0 {
+		root.AddChild(a.danglingNode(dangling))
+	}
 }
 
 func refToID(ref interface{}) string {
@@ -90,6 +113,8 @@ func refToID(ref interface{}) string {
 		return v.ID
 	case *state.Path:
 		return v.Name
+	case *state.Deployment:
+		return "deployment:" + v.Name
 	case string:
 		return v
 	default:
@@ -97,11 +122,42 @@ func refToID(ref interface{}) string {
 	}
 }
 
-func addToTree(creds []*state.Credential) []*tview.TreeNode {
+// isVisible reports whether a credential matches the filters given to the
+// application and the search, or signs a credential which does. The latter
+// keeps the signing chains of matching credentials visible in the tree.
+func (a *Application) isVisible(cred *state.Credential) bool {
+	if visible, found := a.visible[cred]; found {
+		return visible
+	}
+
+	visible := state.AndFilter(a.filters...)(cred) && a.searchFilter(cred)
+	for _, c := range cred.Signs {
+		if visible {
+			break
+		}
+		visible = a.isVisible(c)
+	}
+
+	a.visible[cred] = visible
+	return visible
+}
+
+func (a *Application) addToTree(creds []*state.Credential) []*tview.TreeNode {
 	out := make([]*tview.TreeNode, 0)
 	for _, cred := range creds {
-		pathNode := tview.NewTreeNode(cred.Path.Name).
+		if !a.isVisible(cred) {
+			continue
+		}
+
+		pathLbl := cred.Path.Name
+		if phase := cred.Path.Phase(); phase != state.PhaseIdle {
+			pathLbl = fmt.Sprintf("%s (%s)", pathLbl, phase)
+		}
+		pathNode := tview.NewTreeNode(pathLbl).
 			SetReference(cred.Path).Collapse()
+		if a.changed[cred.Path.Name] {
+			pathNode.SetColor(tcell.ColorGreen)
+		}
 
 		var exists bool
 		for _, n := range out {
@@ -115,18 +171,39 @@ func addToTree(creds []*state.Credential) []*tview.TreeNode {
 		if cred.Transitional {
 			lbl = lbl + " (transitional)"
 		}
+		if a.changed[cred.ID] {
+			lbl = lbl + " (changed)"
+		}
 		credNode := tview.NewTreeNode(lbl).SetReference(cred)
-		switch toStatus(cred) {
-		case "unused":
+		switch {
+		case a.changed[cred.ID]:
+			credNode.SetColor(tcell.ColorGreen)
+		case toStatus(cred) == "unused":
 			credNode.SetColor(tcell.Color102)
-		case "notice":
+		case toStatus(cred) == "notice":
 			credNode.SetColor(tcell.ColorDarkGoldenrod)
 		}
 		pathNode.AddChild(credNode)
-		credNode.SetChildren(addToTree(cred.Signs))
+		credNode.SetChildren(a.addToTree(cred.Signs))
 		if !exists {
 			out = append(out, pathNode)
 		}
 	}
 	return out
 }
+
+// danglingNode lists the deployments referencing credential versions which
+// no longer exist in CredHub.
+func (a *Application) danglingNode(deployments state.Deployments) *tview.TreeNode {
+	node := tview.NewTreeNode("dangling references").
+		SetReference("dangling").SetColor(tcell.ColorRed)
+	for _, d := range deployments {
+		deploymentNode := tview.NewTreeNode(d.Name).SetReference(d).Collapse()
+		for _, ref := range d.Dangling {
+			deploymentNode.AddChild(tview.NewTreeNode(fmt.Sprintf("%s@%s", ref.Name, ref.ID)).
+				SetReference(d).SetColor(tcell.ColorRed))
+		}
+		node.AddChild(deploymentNode)
+	}
+	return node
+}
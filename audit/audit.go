@@ -0,0 +1,180 @@
+// Package audit keeps an append-only JSON Lines log of every change carousel
+// makes to CredHub. Each entry contains the hash of the previous one, so
+// removing or editing an entry breaks the chain, which Verify detects.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	Success = "success"
+	Failure = "failure"
+)
+
+type Entry struct {
+	Timestamp time.Time `json:"timestamp"`
+	// Actor is the UAA client or user carousel authenticated as
+	Actor   string `json:"actor"`
+	Command string `json:"command"`
+	Action  string `json:"action"`
+	Path    string `json:"path"`
+	// VersionBefore is the version acted on, VersionAfter the latest
+	// version of the path after the change
+	VersionBefore string `json:"version_before,omitempty"`
+	VersionAfter  string `json:"version_after,omitempty"`
+	// Source is the version a copied value was read from
+	Source       string `json:"source,omitempty"`
+	Result       string `json:"result"`
+	Error        string `json:"error,omitempty"`
+	PreviousHash string `json:"previous_hash"`
+	Hash         string `json:"hash"`
+}
+
+// hash returns the hash of e chained to its previous hash.
+func (e Entry) hash() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Log appends entries to an audit log file.
+type Log struct {
+	mutex sync.Mutex
+	file  *os.File
+	last  string
+	// Actor and Command are recorded in each entry
+	Actor   string
+	Command string
+}
+
+// Open opens the audit log at path for appending, creating it when it does
+// not exist yet. The chain is verified first, appending to a broken chain is
+// refused as it would hide where it was tampered with.
+func Open(path string) (*Log, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %s got: %s", path, err)
+	}
+
+	entries, last, err := verify(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("refusing to append to audit log: %s which is corrupt after %d valid entries: %s",
+			path, entries, err)
+	}
+
+	return &Log{file: file, last: last}, nil
+}
+
+// Append chains e to the previous entry and writes it, the timestamp, actor
+// and command are filled in when empty.
+func (l *Log) Append(e Entry) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now().UTC()
+	}
+	if e.Actor == "" {
+		e.Actor = l.Actor
+	}
+	if e.Command == "" {
+		e.Command = l.Command
+	}
+	e.PreviousHash = l.last
+
+	var err error
+	if e.Hash, err = e.hash(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log got: %s", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to write audit log got: %s", err)
+	}
+	l.last = e.Hash
+	return nil
+}
+
+func (l *Log) Close() error {
+	return l.file.Close()
+}
+
+// Verify checks the hash chain of the audit log read from r and returns the
+// number of entries, or an error naming the first line which does not match.
+func Verify(r io.Reader) (int, error) {
+	entries, _, err := verify(r)
+	return entries, err
+}
+
+// verify is Verify also returning the hash of the last entry.
+func verify(r io.Reader) (int, string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	line, previous := 0, ""
+	for scanner.Scan() {
+		line++
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return line - 1, previous, fmt.Errorf("line %d: invalid entry got: %s", line, err)
+		}
+		if e.PreviousHash != previous {
+			return line - 1, previous,
+				fmt.Errorf("line %d: previous hash does not match, an entry was removed or reordered", line)
+		}
+		hash, err := e.hash()
+		if err != nil {
+			return line - 1, previous, fmt.Errorf("line %d: %s", line, err)
+		}
+		if hash != e.Hash {
+			return line - 1, previous, fmt.Errorf("line %d: hash does not match, the entry was modified", line)
+		}
+		previous = e.Hash
+	}
+	return line, previous, scanner.Err()
+}
+
+// ActorFromToken returns the user or client name of a UAA access token,
+// without verifying the token.
+func ActorFromToken(token string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+	claims := struct {
+		UserName string `json:"user_name"`
+		ClientID string `json:"client_id"`
+	}{}
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return ""
+	}
+	if claims.UserName != "" {
+		return claims.UserName
+	}
+	return claims.ClientID
+}
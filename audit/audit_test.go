@@ -0,0 +1,173 @@
+package audit_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry-community/carousel/audit"
+	"github.com/cloudfoundry-community/carousel/credhub"
+)
+
+type fakeCredHub struct {
+	credhub.CredHub
+	latest map[string]string
+	err    error
+}
+
+func (f *fakeCredHub) FindLatest(name string) (*credhub.Credential, error) {
+	id, found := f.latest[name]
+	if !found {
+		return nil, errors.New("not found")
+	}
+	return &credhub.Credential{ID: id, Name: name}, nil
+}
+
+func (f *fakeCredHub) ReGenerate(cred *credhub.Credential) error {
+	if f.err == nil {
+		f.latest[cred.Name] = cred.ID + "-regenerated"
+	}
+	return f.err
+}
+
+func (f *fakeCredHub) Copy(cred *credhub.Credential, name string) error {
+	f.latest[name] = cred.ID + "-copy"
+	return nil
+}
+
+var _ = Describe("Audit", func() {
+	var (
+		dir  string
+		path string
+		log  *Log
+	)
+
+	read := func() []string {
+		data, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+
+	verify := func(lines []string) (int, error) {
+		return Verify(bytes.NewBufferString(strings.Join(lines, "\n") + "\n"))
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "audit")
+		Expect(err).ToNot(HaveOccurred())
+		path = filepath.Join(dir, "audit.jsonl")
+		log, err = Open(path)
+		Expect(err).ToNot(HaveOccurred())
+		log.Actor, log.Command = "carousel-client", "carousel rotate"
+	})
+
+	AfterEach(func() {
+		log.Close()
+		os.RemoveAll(dir)
+	})
+
+	Context("with entries", func() {
+		BeforeEach(func() {
+			for _, p := range []string{"/a", "/b", "/c"} {
+				Expect(log.Append(Entry{Action: "regenerate", Path: p, Result: Success})).To(Succeed())
+			}
+		})
+
+		It("writes chained json lines", func() {
+			lines := read()
+			Expect(lines).To(HaveLen(3))
+			Expect(lines[0]).To(ContainSubstring(`"actor":"carousel-client","command":"carousel rotate"`))
+			Expect(lines[0]).To(ContainSubstring(`"previous_hash":""`))
+			Expect(verify(lines)).To(Equal(3))
+		})
+
+		It("continues the chain after reopening", func() {
+			log.Close()
+			var err error
+			log, err = Open(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(log.Append(Entry{Action: "delete", Path: "/d", Result: Success})).To(Succeed())
+			Expect(verify(read())).To(Equal(4))
+		})
+
+		It("detects modified entries", func() {
+			lines := read()
+			lines[1] = strings.Replace(lines[1], `"/b"`, `"/x"`, 1)
+			entries, err := verify(lines)
+			Expect(err).To(MatchError("line 2: hash does not match, the entry was modified"))
+			Expect(entries).To(Equal(1))
+		})
+
+		It("detects removed entries", func() {
+			lines := read()
+			_, err := verify(append(lines[:1], lines[2:]...))
+			Expect(err).To(MatchError(ContainSubstring("line 2: previous hash does not match")))
+		})
+
+		It("refuses to append to a broken chain", func() {
+			log.Close()
+			lines := read()
+			lines[2] = strings.Replace(lines[2], `"/c"`, `"/x"`, 1)
+			Expect(ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600)).To(Succeed())
+
+			_, err := Open(path)
+			Expect(err).To(MatchError(ContainSubstring("corrupt after 2 valid entries: line 3: hash does not match")))
+			Expect(read()).To(Equal(lines))
+		})
+	})
+
+	Describe("CredHub", func() {
+		var fake *fakeCredHub
+
+		BeforeEach(func() {
+			fake = &fakeCredHub{latest: map[string]string{"/a": "a-1"}}
+		})
+
+		It("records the versions before and after a change", func() {
+			ch := CredHub(fake, log)
+			Expect(ch.ReGenerate(&credhub.Credential{ID: "a-1", Name: "/a"})).To(Succeed())
+			Expect(ch.Copy(&credhub.Credential{ID: "a-1", Name: "/a"}, "/a_previous")).To(Succeed())
+
+			lines := read()
+			Expect(lines).To(HaveLen(2))
+			Expect(lines[0]).To(ContainSubstring(
+				`"action":"regenerate","path":"/a","version_before":"a-1","version_after":"a-1-regenerated"`))
+			Expect(lines[1]).To(ContainSubstring(
+				`"action":"copy","path":"/a_previous","version_after":"a-1-copy","source":"/a@a-1"`))
+			Expect(verify(lines)).To(Equal(2))
+		})
+
+		It("records failures", func() {
+			fake.err = errors.New("boom")
+			err := CredHub(fake, log).ReGenerate(&credhub.Credential{ID: "a-1", Name: "/a"})
+			Expect(err).To(MatchError("boom"))
+			Expect(read()[0]).To(ContainSubstring(`"result":"failure","error":"boom"`))
+		})
+	})
+
+	Describe("ActorFromToken", func() {
+		token := func(claims string) string {
+			return "header." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".signature"
+		}
+
+		It("prefers the user name", func() {
+			Expect(ActorFromToken(token(`{"user_name":"admin","client_id":"credhub_cli"}`))).To(Equal("admin"))
+		})
+
+		It("falls back to the client", func() {
+			Expect(ActorFromToken(token(`{"client_id":"credhub_cli"}`))).To(Equal("credhub_cli"))
+		})
+
+		It("ignores invalid tokens", func() {
+			Expect(ActorFromToken("invalid")).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"github.com/cloudfoundry-community/carousel/credhub"
+)
+
+// CredHub wraps ch to record each change in log. Failing to write an entry
+// is returned as error of a change which succeeded.
+func CredHub(ch credhub.CredHub, log *Log) credhub.CredHub {
+	return &auditedCredHub{CredHub: ch, log: log}
+}
+
+type auditedCredHub struct {
+	credhub.CredHub
+	log *Log
+}
+
+func (a *auditedCredHub) ReGenerate(cred *credhub.Credential) error {
+	return a.record(Entry{Action: "regenerate", Path: cred.Name, VersionBefore: cred.ID}, func() error {
+		return a.CredHub.ReGenerate(cred)
+	})
+}
+
+func (a *auditedCredHub) Delete(cred *credhub.Credential) error {
+	return a.record(Entry{Action: "delete", Path: cred.Name, VersionBefore: cred.ID}, func() error {
+		return a.CredHub.Delete(cred)
+	})
+}
+
+func (a *auditedCredHub) UpdateTransitional(cred *credhub.Credential, remove bool) error {
+	action := "mark_transitional"
+	if remove {
+		action = "unmark_transitional"
+	}
+	return a.record(Entry{Action: action, Path: cred.Name, VersionBefore: cred.ID}, func() error {
+		return a.CredHub.UpdateTransitional(cred, remove)
+	})
+}
+
+func (a *auditedCredHub) Copy(cred *credhub.Credential, name string) error {
+	entry := Entry{Action: "copy", Path: name, Source: cred.Name + "@" + cred.ID}
+	if latest, err := a.CredHub.FindLatest(name); err == nil {
+		entry.VersionBefore = latest.ID
+	}
+	return a.record(entry, func() error {
+		return a.CredHub.Copy(cred, name)
+	})
+}
+
+// record makes the change with fn and appends entry with its result.
+func (a *auditedCredHub) record(entry Entry, fn func() error) error {
+	err := fn()
+	entry.Result = Success
+	if err != nil {
+		entry.Result, entry.Error = Failure, err.Error()
+	}
+	if latest, findErr := a.CredHub.FindLatest(entry.Path); findErr == nil {
+		entry.VersionAfter = latest.ID
+	}
+
+	if logErr := a.log.Append(entry); logErr != nil && err == nil {
+		return logErr
+	}
+	return err
+}
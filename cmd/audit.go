@@ -0,0 +1,64 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cloudfoundry-community/carousel/audit"
+)
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the audit log of changes made to CredHub",
+}
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify [file]",
+	Short: "Verify the hash chain of the audit log",
+	Long: `Checks that no entry of the audit log (given as argument, with --audit-log or
+CAROUSEL_AUDIT_LOG) has been modified, removed or reordered.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := auditLogFile
+		if len(args) == 1 {
+			path = args[0]
+		}
+		if path == "" {
+			logger.Fatal("no audit log given")
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			logger.Fatalf("failed to open audit log: %s got: %s", path, err)
+		}
+		defer file.Close()
+
+		entries, err := audit.Verify(file)
+		if err != nil {
+			logger.Fatalf("audit log %s is corrupt after %d valid entries: %s", path, entries, err)
+		}
+		cmd.Printf("Verified %d entries of %s\n", entries, path)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditVerifyCmd)
+}
@@ -16,11 +16,15 @@ limitations under the License.
 package cmd
 
 import (
+	"time"
+
 	"github.com/spf13/cobra"
 
 	"github.com/cloudfoundry-community/carousel/app"
 )
 
+var browseRefreshInterval time.Duration
+
 // browseCmd represents the browse command
 var browseCmd = &cobra.Command{
 	Use:   "browse",
@@ -32,7 +36,16 @@ way of browsing trought certificate signing chains in a tree like fashion.`,
 		initialize()
 		refresh()
 
-		app := app.NewApplication(state, credhub, refresh).Init()
+		regenerationCriteria, err := criteria.RegenerationCriteria()
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		app := app.NewApplication(state, credhub, tryRefresh, regenerationCriteria,
+			filters.Filters()...).
+			WithAutoRefresh(browseRefreshInterval).
+			WithAuditLog(auditLog).
+			Init()
 
 		if err := app.Run(); err != nil {
 			logger.Fatalf("the browse encountered an error: %s", err)
@@ -44,6 +57,15 @@ way of browsing trought certificate signing chains in a tree like fashion.`,
 func init() {
 	rootCmd.AddCommand(browseCmd)
 
+	addDeploymentFlag(browseCmd.Flags())
+	addPathFlags(browseCmd.Flags())
+	addWhereFlag(browseCmd.Flags())
+	addExpiresWithinCriteriaFlag(browseCmd.Flags())
+	addOlderThanCireteriaFlag(browseCmd.Flags())
+	addIgnoreUpdateModeCireteriaFlag(browseCmd.Flags())
+	browseCmd.Flags().DurationVar(&browseRefreshInterval, "refresh-interval", 0,
+		"refresh the state in the background on this interval, e.g. 5m (default only on ^R)")
+
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
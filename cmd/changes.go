@@ -0,0 +1,168 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	cstate "github.com/cloudfoundry-community/carousel/state"
+)
+
+var (
+	changesBefore string
+	changesAfter  string
+	changesSave   string
+	changesOutput string
+)
+
+// changesCmd represents the changes command
+var changesCmd = &cobra.Command{
+	Use:   "changes",
+	Short: "Show what changed between two snapshots of the state",
+	Long: `Record a snapshot of the state before a maintenance window with --save,
+and compare it afterwards with the live state or another saved snapshot.
+Snapshots contain credential versions, transitional flags and deployments
+but no credential values.
+
+  carousel changes --save before.json
+  carousel changes --before before.json --output markdown`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if changesSave == "" && changesBefore == "" {
+			logger.Fatal("either --save or --before is required")
+		}
+
+		if changesSave != "" {
+			initialize()
+			refresh()
+			err := writeSnapshotRecord(changesSave, cstate.Record(state.Snapshot()))
+			if err != nil {
+				logger.Fatal(err)
+			}
+			cmd.Printf("Saved snapshot to %s\n", changesSave)
+			return
+		}
+
+		before, err := readSnapshotRecord(changesBefore)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		var after cstate.SnapshotRecord
+		if changesAfter != "" {
+			after, err = readSnapshotRecord(changesAfter)
+			if err != nil {
+				logger.Fatal(err)
+			}
+		} else {
+			initialize()
+			refresh()
+			after = cstate.Record(state.Snapshot())
+		}
+
+		err = renderChangeLog(cmd.OutOrStdout(), cstate.Compare(before, after), changesOutput)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(changesCmd)
+
+	changesCmd.Flags().StringVar(&changesBefore, "before", "",
+		"snapshot file to compare from")
+	changesCmd.Flags().StringVar(&changesAfter, "after", "",
+		"snapshot file to compare to (defaults to the live state)")
+	changesCmd.Flags().StringVar(&changesSave, "save", "",
+		"save a snapshot of the live state to file instead of comparing")
+	changesCmd.Flags().StringVarP(&changesOutput, "output", "o", "text",
+		"output format: text, json or markdown")
+}
+
+func readSnapshotRecord(file string) (cstate.SnapshotRecord, error) {
+	var out cstate.SnapshotRecord
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return out, fmt.Errorf("failed to read snapshot: %s got: %s", file, err)
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return out, fmt.Errorf("failed to parse snapshot: %s got: %s", file, err)
+	}
+	return out, nil
+}
+
+func writeSnapshotRecord(file string, record cstate.SnapshotRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot got: %s", err)
+	}
+	if err := ioutil.WriteFile(file, data, 0600); err != nil {
+		return fmt.Errorf("failed to write snapshot: %s got: %s", file, err)
+	}
+	return nil
+}
+
+func renderChangeLog(w io.Writer, log cstate.ChangeLog, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(log)
+	case "markdown":
+		fmt.Fprintf(w, "## Changes from %s to %s\n\n",
+			log.Before.Format(time.RFC3339), log.After.Format(time.RFC3339))
+		if len(log.Changes) == 0 {
+			fmt.Fprintf(w, "No changes\n")
+			return nil
+		}
+		fmt.Fprintf(w, "| Path | Version | Change | Deployment |\n")
+		fmt.Fprintf(w, "|------|---------|--------|------------|\n")
+		for _, c := range log.Changes {
+			fmt.Fprintf(w, "| `%s` | `%s` | %s | %s |\n",
+				c.Name, c.ID, c.Kind, c.Deployment)
+		}
+		return nil
+	case "text":
+		fmt.Fprintf(w, "Changes from %s to %s:\n",
+			log.Before.Format(time.RFC3339), log.After.Format(time.RFC3339))
+		if len(log.Changes) == 0 {
+			fmt.Fprintf(w, "No changes\n")
+			return nil
+		}
+		var name string
+		for _, c := range log.Changes {
+			if c.Name != name {
+				name = c.Name
+				fmt.Fprintf(w, "%s\n", name)
+			}
+			line := []string{"-", c.Kind.String(), c.ID}
+			if c.Deployment != "" {
+				line = append(line, c.Deployment)
+			}
+			fmt.Fprintf(w, "  %s\n", strings.Join(line, " "))
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format: %s (use text, json or markdown)", format)
+	}
+}
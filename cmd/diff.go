@@ -44,8 +44,9 @@ var diffCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		initialize()
 
-		if filters.deployment == "" {
-			logger.Fatal("deployment flag must be set")
+		deployment, err := filters.deployment()
+		if err != nil {
+			logger.Fatal(err)
 		}
 
 		refresh()
@@ -53,7 +54,7 @@ var diffCmd = &cobra.Command{
 		latest := state.Credentials(append(filters.Filters(), cstate.LatestFilter())...)
 		active := state.Credentials(append(filters.Filters(), cstate.ActiveFilter())...)
 
-		manfest, err := director.GetManifest(filters.deployment)
+		manfest, err := director.GetManifest(deployment)
 		if err != nil {
 			logger.Fatalf("failed to get bosh manifest: %s", err)
 		}
@@ -70,10 +71,10 @@ var diffCmd = &cobra.Command{
 		}
 		activeNames := []string{"manifest"}
 
-		appendConfigs(director.GetLatestCloudConfigs, latest, &latestYAML, &latestNames)
-		appendConfigs(director.GetActiveCloudConfigs, active, &activeYAML, &activeNames)
-		appendConfigs(director.GetLatestRuntimeConfigs, latest, &latestYAML, &latestNames)
-		appendConfigs(director.GetActiveRuntimeConfigs, active, &activeYAML, &activeNames)
+		appendConfigs(director.GetLatestCloudConfigs, deployment, latest, &latestYAML, &latestNames)
+		appendConfigs(director.GetActiveCloudConfigs, deployment, active, &activeYAML, &activeNames)
+		appendConfigs(director.GetLatestRuntimeConfigs, deployment, latest, &latestYAML, &latestNames)
+		appendConfigs(director.GetActiveRuntimeConfigs, deployment, active, &activeYAML, &activeNames)
 
 		report, err := dyff.CompareInputFiles(ytbx.InputFile{
 			Documents: activeYAML,
@@ -108,6 +109,8 @@ func init() {
 	rootCmd.AddCommand(diffCmd)
 
 	addDeploymentFlag(diffCmd.Flags())
+	addPathFlags(diffCmd.Flags())
+	addWhereFlag(diffCmd.Flags())
 	diffCmd.Flags().BoolVar(&doNotInspectCerts, "do-not-inspect-certs", false,
 		"don't show a human readable diff for certificates")
 	diffCmd.Flags().BoolVar(&showCredentialMeta, "show-credential-meta", false,
@@ -118,9 +121,9 @@ type cred struct {
 	Type        string `yaml:"type"`
 	Version     string `yaml:"version"`
 	CreatedAt   string `yaml:"created_at"`
-	Certificate string `yaml:"certificate",omitempty`
-	Ca          string `yaml:"ca",omitempty`
-	Expiry      string `yaml:"expiry",omitempty`
+	Certificate string `yaml:"certificate,omitempty"`
+	Ca          string `yaml:"ca,omitempty"`
+	Expiry      string `yaml:"expiry,omitempty"`
 }
 
 func renderTemplate(manifest []byte, creds cstate.Credentials) ([]*yaml.Node, error) {
@@ -153,9 +156,9 @@ func renderTemplate(manifest []byte, creds cstate.Credentials) ([]*yaml.Node, er
 	return ytbx.LoadYAMLDocuments(bytes)
 }
 
-func appendConfigs(fn func(deployment string) (map[string][]byte, error),
+func appendConfigs(fn func(deployment string) (map[string][]byte, error), deployment string,
 	creds cstate.Credentials, appendTo *[]*yaml.Node, names *[]string) {
-	confs, err := fn(filters.deployment)
+	confs, err := fn(deployment)
 	if err != nil {
 		logger.Fatalf("failed to get latest cloud configs: %s", err)
 	}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	cstate "github.com/cloudfoundry-community/carousel/state"
+)
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Report problems which keep carousel from rotating credentials",
+	Long: `Reports deployments referencing credential versions which CredHub no
+longer has, certificates whose issuer could not be verified or is ambiguous
+and malformed credential versions left out of the state. Exits non-zero when
+any problem is found.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		initialize()
+		refresh()
+
+		dangling := state.Deployments().Dangling().Select(filters.selectsDeployment)
+		printDangling(cmd, dangling)
+
+		flagged := state.Credentials(append(filters.Filters(),
+			cstate.LatestFilter(), cstate.FlaggedIssuerFilter())...)
+		flagged.SortByNameAndCreatedAt()
+		if len(flagged) != 0 {
+			cmd.Printf("Found certificate(s) with an ambiguous or unverified issuer:\n")
+			for _, cred := range flagged {
+				cmd.Printf("- %s (issuer %s)\n  L %s\n",
+					cred.PathVersion(), cred.IssuerStatus, cred.Summary())
+			}
+			cmd.Println("")
+		}
+
+		skipped := state.Skipped()
+		if len(skipped) != 0 {
+			cmd.Printf("Found malformed credential version(s) carousel ignores:\n")
+			for _, version := range skipped {
+				cmd.Printf("- %s@%s (%s)\n", version.Name, version.ID, version.Reason)
+			}
+			cmd.Println("")
+		}
+
+		if len(dangling) == 0 && len(flagged) == 0 && len(skipped) == 0 {
+			cmd.Printf("No problems found\n")
+			return
+		}
+		os.Exit(1)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	addDeploymentFlag(doctorCmd.Flags())
+	addPathFlags(doctorCmd.Flags())
+	addWhereFlag(doctorCmd.Flags())
+}
+
+// printDangling lists the dangling references of deployments.
+func printDangling(cmd *cobra.Command, deployments cstate.Deployments) {
+	if len(deployments) == 0 {
+		return
+	}
+	cmd.Printf("Found deployment(s) referencing deleted credentials, run bosh deploy to converge:\n")
+	for _, d := range deployments {
+		cmd.Printf("- %s\n", d.Name)
+		for _, ref := range d.Dangling {
+			cmd.Printf("  L %s@%s\n", ref.Name, ref.ID)
+		}
+	}
+	cmd.Println("")
+}
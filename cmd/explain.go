@@ -0,0 +1,76 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	cstate "github.com/cloudfoundry-community/carousel/state"
+)
+
+// explainCmd represents the explain command
+var explainCmd = &cobra.Command{
+	Use:   "explain <path>",
+	Short: "Explain the action rotate decides for each version of a credential",
+	Long: `Shows for each version of the given path the action rotate would take,
+the rule deciding it and the values that rule compared, e.g. the expiry date
+of a certificate and the --expires-within criteria.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		initialize()
+		refresh()
+
+		regenerationCriteria, err := criteria.RegenerationCriteria()
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		credentials := state.Credentials(cstate.NameFilter(args[0]))
+		if len(credentials) == 0 {
+			logger.Fatalf("credential not found: %s", args[0])
+		}
+		credentials.SortByNameAndCreatedAt()
+
+		cmd.Printf("%s\n", args[0])
+		for _, cred := range credentials {
+			decision := cred.Decide(regenerationCriteria)
+			cmd.Printf("- %s\n  L %s\n", cred.ID, cred.Summary())
+			printDecision(cmd, decision, "    ")
+		}
+	},
+}
+
+// printDecision prints the action, the rule deciding it and the values it
+// compared, one per line.
+func printDecision(cmd *cobra.Command, decision cstate.Decision, indent string) {
+	if decision.Rule == "" {
+		cmd.Printf("%s%s, no rule applies\n", indent, decision.Action)
+		return
+	}
+	cmd.Printf("%s%s by rule %s\n", indent, decision.Action, decision.Rule)
+	for _, v := range decision.Values {
+		cmd.Printf("%s  %s: %s\n", indent, v.Name, v.Value)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+
+	addExpiresWithinCriteriaFlag(explainCmd.Flags())
+	addOlderThanCireteriaFlag(explainCmd.Flags())
+	addIgnoreUpdateModeCireteriaFlag(explainCmd.Flags())
+	addNowFlag(explainCmd.Flags())
+}
@@ -2,11 +2,16 @@ package cmd
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/karrick/tparse"
 	"github.com/spf13/pflag"
+
 	ccredhub "github.com/cloudfoundry-community/carousel/credhub"
+	cpolicy "github.com/cloudfoundry-community/carousel/policy"
+	"github.com/cloudfoundry-community/carousel/query"
 	. "github.com/cloudfoundry-community/carousel/state"
 	cstate "github.com/cloudfoundry-community/carousel/state"
 )
@@ -15,52 +20,122 @@ type actionCriteria struct {
 	expiresWithin    string
 	olderThan        string
 	ignoreUpdateMode bool
+	now              string
+	maxActions       int
+	maxDeployments   int
 }
 
 var criteria = actionCriteria{}
 
+// Now returns the time given with --now, or the current time.
+func (c actionCriteria) Now() time.Time {
+	if c.now == "" {
+		return time.Now()
+	}
+	now, err := cpolicy.ParseTime(c.now)
+	if err != nil {
+		logger.Fatalf("failed to parse --now flag: %s", err)
+	}
+	return now
+}
+
+// Rollout returns the rollout of the policy with the limits given by
+// --max-actions and --max-deployments.
+func (c actionCriteria) Rollout(rollout cpolicy.Rollout) cpolicy.Rollout {
+	if c.maxActions > 0 {
+		rollout.MaxActions = c.maxActions
+	}
+	if c.maxDeployments > 0 {
+		rollout.MaxDeployments = c.maxDeployments
+	}
+	return rollout
+}
+
 func (c actionCriteria) RegenerationCriteria() (cstate.RegenerationCriteria, error) {
-	ew, err := tparse.AddDuration(time.Now(), "+"+c.expiresWithin)
+	now := c.Now()
+	ew, err := tparse.AddDuration(now, "+"+c.expiresWithin)
 	if err != nil {
 		return cstate.RegenerationCriteria{},
 			fmt.Errorf("failed to parse --expires-within flag into duration: %s, got: %s",
 				c.expiresWithin, err)
 	}
 
-	ot, err := tparse.AddDuration(time.Now(), "-"+c.olderThan)
+	ot, err := tparse.AddDuration(now, "-"+c.olderThan)
 	if err != nil {
 		return cstate.RegenerationCriteria{},
 			fmt.Errorf("failed to parse --older-than flag into duration: %s, got: %s",
 				c.olderThan, err)
 	}
 
+	rules, err := policy.RuleSet(now)
+	if err != nil {
+		return cstate.RegenerationCriteria{}, err
+	}
+
 	return cstate.RegenerationCriteria{
 		OlderThan:        ot,
 		ExpiresBefore:    ew,
 		IgnoreUpdateMode: c.ignoreUpdateMode,
+		Rules:            rules,
 	}, nil
 }
 
 type credentialFilters struct {
-	deployment    string
-	name          string
-	types         []string
-	unused        bool
-	expiresWithin string
-	olderThan     string
-	latest        bool
-	signing       bool
-	signedBy      string
-	ca            bool
-	leaf          bool
+	deployments              []string
+	excludeDeployments       []string
+	deploymentRegexes        []string
+	excludeDeploymentRegexes []string
+	paths                    []string
+	excludePaths             []string
+	pathRegexes              []string
+	excludePathRegexes       []string
+	where                    []string
+	name                     string
+	types                    []string
+	unused                   bool
+	expiresWithin            string
+	olderThan                string
+	latest                   bool
+	signing                  bool
+	signedBy                 string
+	ca                       bool
+	leaf                     bool
 }
 
 var filters = credentialFilters{}
 
 func (f credentialFilters) Filters() []Filter {
 	out := make([]Filter, 0)
-	if f.deployment != "" {
-		out = append(out, DeploymentFilter(f.deployment))
+	if len(f.deployments) != 0 {
+		out = append(out, DeploymentGlobFilter(mustGlobs(f.deployments)...))
+	}
+	if len(f.excludeDeployments) != 0 {
+		out = append(out, NotFilter(DeploymentGlobFilter(mustGlobs(f.excludeDeployments)...)))
+	}
+	if len(f.deploymentRegexes) != 0 {
+		out = append(out, DeploymentRegexFilter(mustRegexes(f.deploymentRegexes)...))
+	}
+	if len(f.excludeDeploymentRegexes) != 0 {
+		out = append(out, NotFilter(DeploymentRegexFilter(mustRegexes(f.excludeDeploymentRegexes)...)))
+	}
+	if len(f.paths) != 0 {
+		out = append(out, PathGlobFilter(mustGlobs(f.paths)...))
+	}
+	if len(f.excludePaths) != 0 {
+		out = append(out, NotFilter(PathGlobFilter(mustGlobs(f.excludePaths)...)))
+	}
+	if len(f.pathRegexes) != 0 {
+		out = append(out, PathRegexFilter(mustRegexes(f.pathRegexes)...))
+	}
+	if len(f.excludePathRegexes) != 0 {
+		out = append(out, NotFilter(PathRegexFilter(mustRegexes(f.excludePathRegexes)...)))
+	}
+	for _, expr := range f.where {
+		filter, err := query.Parse(expr)
+		if err != nil {
+			logger.Fatalf("failed to parse --where flag: %s", err)
+		}
+		out = append(out, filter)
 	}
 	if f.name != "" {
 		out = append(out, NameFilter(f.name))
@@ -77,11 +152,7 @@ func (f credentialFilters) Filters() []Filter {
 		out = append(out, TypeFilter(types...))
 	}
 	if f.unused {
-		out = append(out, AndFilter(
-			NotFilter(ActiveFilter()),
-			NotFilter(TransitionalFilter()),
-			NotFilter(AnyFilter(SignsCollector())),
-		))
+		out = append(out, UnusedFilter())
 	}
 	if f.expiresWithin != "" {
 		ew, err := tparse.AddDuration(time.Now(), "+"+f.expiresWithin)
@@ -122,15 +193,80 @@ func (f credentialFilters) Filters() []Filter {
 	return out
 }
 
+// selectsDeployment reports whether d is selected by the deployment flags.
+func (f credentialFilters) selectsDeployment(d *Deployment) bool {
+	if len(f.deployments) != 0 && !MatchesGlob(d.Name, mustGlobs(f.deployments)...) {
+		return false
+	}
+	if len(f.deploymentRegexes) != 0 && !MatchesRegex(d.Name, mustRegexes(f.deploymentRegexes)...) {
+		return false
+	}
+	return !MatchesGlob(d.Name, mustGlobs(f.excludeDeployments)...) &&
+		!MatchesRegex(d.Name, mustRegexes(f.excludeDeploymentRegexes)...)
+}
+
+// deployment returns the single deployment selected with --deployment, for
+// commands which operate on exactly one deployment.
+func (f credentialFilters) deployment() (string, error) {
+	if len(f.deployments) != 1 {
+		return "", fmt.Errorf("exactly one deployment must be given using the deployment flag")
+	}
+	if strings.ContainsAny(f.deployments[0], "*?[\\") {
+		return "", fmt.Errorf("deployment flag must be a deployment name not a pattern: %s",
+			f.deployments[0])
+	}
+	return f.deployments[0], nil
+}
+
+func mustGlobs(patterns []string) []string {
+	if err := ValidateGlobs(patterns...); err != nil {
+		logger.Fatal(err)
+	}
+	return patterns
+}
+
+func mustRegexes(exprs []string) []*regexp.Regexp {
+	out := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			logger.Fatalf("failed to parse regex: %s, got: %s", expr, err)
+		}
+		out = append(out, re)
+	}
+	return out
+}
+
 func addTypesFlag(set *pflag.FlagSet) {
 	set.StringSliceVarP(&filters.types, "types", "t", ccredhub.CredentialTypeStringValues(),
 		"filter by credential type (comma separated)")
 }
 
 func addDeploymentFlag(set *pflag.FlagSet) {
-	set.StringVarP(&filters.deployment, "deployment", "d", "",
-		"filter by deployment name")
+	set.StringSliceVarP(&filters.deployments, "deployment", "d", nil,
+		"only credentials used by deployments matching glob (comma separated)")
+	set.StringSliceVar(&filters.excludeDeployments, "exclude-deployment", nil,
+		"skip credentials used by deployments matching glob (comma separated)")
+	set.StringArrayVar(&filters.deploymentRegexes, "deployment-regex", nil,
+		"only credentials used by deployments matching regex")
+	set.StringArrayVar(&filters.excludeDeploymentRegexes, "exclude-deployment-regex", nil,
+		"skip credentials used by deployments matching regex")
+}
 
+func addPathFlags(set *pflag.FlagSet) {
+	set.StringSliceVar(&filters.paths, "path", nil,
+		"only credentials with a path matching glob (comma separated)")
+	set.StringSliceVar(&filters.excludePaths, "exclude-path", nil,
+		"skip credentials with a path matching glob (comma separated)")
+	set.StringArrayVar(&filters.pathRegexes, "path-regex", nil,
+		"only credentials with a path matching regex")
+	set.StringArrayVar(&filters.excludePathRegexes, "exclude-path-regex", nil,
+		"skip credentials with a path matching regex")
+}
+
+func addWhereFlag(set *pflag.FlagSet) {
+	set.StringArrayVarP(&filters.where, "where", "w", nil,
+		"only credentials matching expression, e.g. 'type = certificate and expires_in < 60d'")
 }
 
 func addNameFlag(set *pflag.FlagSet) {
@@ -168,3 +304,15 @@ func addIgnoreUpdateModeCireteriaFlag(set *pflag.FlagSet) {
 	set.BoolVar(&criteria.ignoreUpdateMode, "ignore-update-mode", false,
 		"ignore the value of BOSH /variables/.../update_mode")
 }
+
+func addNowFlag(set *pflag.FlagSet) {
+	set.StringVar(&criteria.now, "now", "",
+		"act as if it is this time, e.g. to test maintenance windows (e.g. 2024-12-24T22:00)")
+}
+
+func addRolloutFlags(set *pflag.FlagSet) {
+	set.IntVar(&criteria.maxActions, "max-actions", 0,
+		"perform at most this many actions per run (default from policy, 0 is unlimited)")
+	set.IntVar(&criteria.maxDeployments, "max-deployments", 0,
+		"affect at most this many deployments per run (default from policy, 0 is unlimited)")
+}
@@ -1,36 +1,49 @@
 package cmd
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"sync"
 
+	chconfig "code.cloudfoundry.org/credhub-cli/config"
 	credhubcli "code.cloudfoundry.org/credhub-cli/credhub"
 	"code.cloudfoundry.org/credhub-cli/credhub/auth"
+	"github.com/cloudfoundry-community/carousel/audit"
 	cbosh "github.com/cloudfoundry-community/carousel/bosh"
 	"github.com/cloudfoundry-community/carousel/config"
 	ccredhub "github.com/cloudfoundry-community/carousel/credhub"
+	"github.com/cloudfoundry-community/carousel/hooks"
+	cpolicy "github.com/cloudfoundry-community/carousel/policy"
 	. "github.com/cloudfoundry-community/carousel/state"
 )
 
 var (
-	logger   *log.Logger
+	logger   = log.New(os.Stderr, "", 0)
 	credhub  ccredhub.CredHub
 	director cbosh.Director
 	state    State
+	policy   *cpolicy.Policy
+	auditLog *audit.Log
+	runner   *hooks.Runner
 )
 
 func initialize() {
-	logger = log.New(os.Stderr, "", 0)
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		logger.Fatalf("failed to load environment configuration: %s", err)
 	}
 
+	chauth := auth.UaaClientCredentials(cfg.Credhub.Client, cfg.Credhub.Secret)
+	if cfg.Credhub.UsesTokens() {
+		chauth = auth.Uaa(chconfig.AuthClient, chconfig.AuthPassword, "", "",
+			cfg.Credhub.AccessToken, cfg.Credhub.RefreshToken, false)
+	}
+
 	chcli, err := credhubcli.New(
 		cfg.Credhub.Server,
 		credhubcli.SkipTLSValidation(true), // TODO use CA
-		credhubcli.Auth(auth.UaaClientCredentials(cfg.Credhub.Client, cfg.Credhub.Secret)),
+		credhubcli.Auth(chauth),
 	)
 	if err != nil {
 		logger.Fatalf("failed to connect to Credhub: %s", err)
@@ -38,43 +51,96 @@ func initialize() {
 
 	credhub = ccredhub.NewCredHub(chcli)
 
+	if auditLogFile != "" {
+		auditLog, err = audit.Open(auditLogFile)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		auditLog.Actor = cfg.Credhub.Client
+		if auditLog.Actor == "" {
+			auditLog.Actor = audit.ActorFromToken(cfg.Credhub.AccessToken)
+		}
+		auditLog.Command = commandPath
+		credhub = audit.CredHub(credhub, auditLog)
+	}
+
 	director, err = cbosh.NewDirector(cfg.Bosh)
 	if err != nil {
 		logger.Fatalf("failed to connect to BOSH Director: %s", err)
 	}
 
+	policy, err = cpolicy.Load(policyFile)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	runner, err = policy.HookRunner()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	runner.Command = commandPath
+	runner.Output = os.Stderr
+
 	state = NewState()
 }
 
-func refresh() error {
+// reportedSkipped are the IDs of the skipped versions already reported, so
+// refreshing in every round of a command only reports them once.
+var reportedSkipped = make(map[string]bool)
+
+func refresh() {
+	credentials, variables, err := load()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	if err = state.Update(credentials, variables); err != nil {
+		logger.Fatal(err)
+	}
+	for _, skipped := range state.Skipped() {
+		if !reportedSkipped[skipped.ID] {
+			reportedSkipped[skipped.ID] = true
+			logger.Printf("Skipped malformed credential version %s@%s: %s", skipped.Name, skipped.ID, skipped.Reason)
+		}
+	}
+}
+
+// tryRefresh is refresh returning failures to load from Credhub or the BOSH
+// Director instead of exiting, e.g. to keep the browse UI running.
+func tryRefresh() error {
+	credentials, variables, err := load()
+	if err != nil {
+		return err
+	}
+	return state.Update(credentials, variables)
+}
+
+func load() ([]*ccredhub.Credential, []*cbosh.Variable, error) {
 	var (
-		wg          sync.WaitGroup
-		err         error
-		credentials []*ccredhub.Credential
-		variables   []*cbosh.Variable
+		wg                           sync.WaitGroup
+		credentialsErr, variablesErr error
+		credentials                  []*ccredhub.Credential
+		variables                    []*cbosh.Variable
 	)
 
 	wg.Add(1)
 	go func(wg *sync.WaitGroup, credentials *[]*ccredhub.Credential) {
 		defer wg.Done()
-		*credentials, err = credhub.FindAll()
-		if err != nil {
-			logger.Fatalf("failed to load credentials from Credhub: %s", err)
-		}
-
+		*credentials, credentialsErr = credhub.FindAll()
 	}(&wg, &credentials)
 
 	wg.Add(1)
 	go func(wg *sync.WaitGroup, variables *[]*cbosh.Variable) {
 		defer wg.Done()
-		*variables, err = director.GetVariables()
-		if err != nil {
-			logger.Fatalf("failed to load variables from BOSH Director: %s", err)
-		}
-
+		*variables, variablesErr = director.GetVariables()
 	}(&wg, &variables)
 
 	wg.Wait()
 
-	return state.Update(credentials, variables)
+	if credentialsErr != nil {
+		return nil, nil, fmt.Errorf("failed to load credentials from Credhub: %s", credentialsErr)
+	}
+	if variablesErr != nil {
+		return nil, nil, fmt.Errorf("failed to load variables from BOSH Director: %s", variablesErr)
+	}
+	return credentials, variables, nil
 }
@@ -0,0 +1,63 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	cstate "github.com/cloudfoundry-community/carousel/state"
+)
+
+// impactCmd represents the impact command
+var impactCmd = &cobra.Command{
+	Use:   "impact <path>",
+	Short: "Show what is affected by rotating a credential",
+	Long: `Lists every credential signed by or referencing the given path in its ca
+bundle, directly or through intermediate certificate authorities, and the
+deployments using them. Also estimates the number of deploy rounds a full
+rotation needs and suggests a deploy order.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		initialize()
+		refresh()
+
+		cred, found := state.Credentials(cstate.NameFilter(args[0])).Find(cstate.LatestFilter())
+		if !found {
+			logger.Fatalf("credential not found: %s", args[0])
+		}
+
+		impact := cred.Path.Impact()
+
+		cmd.Printf("Affected credentials:\n")
+		for _, ip := range impact.Paths {
+			latest := ip.Path.Versions[0]
+			cmd.Printf("- %s (level %d, %d versions)\n  L %s\n",
+				ip.Path.Name, ip.Depth, len(ip.Path.Versions), latest.Summary())
+		}
+		cmd.Println("")
+
+		cmd.Printf("Affected deployments: %s\n\n", impact.Deployments.String())
+
+		cmd.Printf("Estimated deploy rounds: %d\n", len(impact.Rounds))
+		for i, round := range impact.Rounds {
+			cmd.Printf("%d. %s\n   L %s\n", i+1, round.Description, round.Deployments.String())
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(impactCmd)
+}
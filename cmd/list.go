@@ -0,0 +1,124 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	ccredhub "github.com/cloudfoundry-community/carousel/credhub"
+	cstate "github.com/cloudfoundry-community/carousel/state"
+)
+
+var listIncludeAll bool
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List credentials and the deployments using them",
+	Long: `Lists CredHub credentials by path, augmented with information from the
+BOSH director: the deployments using each version, its flags and, for
+certificate authorities, the rotation phase of the path. Only versions which
+are the latest or in use are listed, unless --include-all is given.
+Deployments referencing versions which CredHub no longer has are listed last.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		initialize()
+		refresh()
+
+		credentials := state.Credentials(filters.Filters()...)
+		if !listIncludeAll && !filters.unused {
+			credentials = credentials.Select(cstate.OrFilter(cstate.LatestFilter(), cstate.ActiveFilter()))
+		}
+		credentials.SortByNameAndCreatedAt()
+
+		renderList(cmd.OutOrStdout(), credentials)
+		if dangling := state.Deployments().Dangling().Select(filters.selectsDeployment); len(dangling) != 0 {
+			cmd.Println("")
+			printDangling(cmd, dangling)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+
+	addDeploymentFlag(listCmd.Flags())
+	addPathFlags(listCmd.Flags())
+	addWhereFlag(listCmd.Flags())
+	addTypesFlag(listCmd.Flags())
+	addSignedByFlag(listCmd.Flags())
+	addExpiresWithinFlag(listCmd.Flags())
+	addOlderThanFlag(listCmd.Flags())
+
+	listCmd.Flags().BoolVar(&listIncludeAll, "include-all", false,
+		"also show unused credential versions")
+	listCmd.Flags().BoolVar(&filters.unused, "unused", false,
+		"only show unused credential versions")
+	listCmd.Flags().BoolVar(&filters.signing, "signing", false,
+		"only show certificates used to sign")
+	listCmd.Flags().BoolVar(&filters.ca, "ca", false,
+		"only show certificate authorities")
+	listCmd.Flags().BoolVar(&filters.leaf, "leaf", false,
+		"only show leaf certificates")
+}
+
+// renderList prints credentials grouped by path, credentials must be sorted
+// by name.
+func renderList(w io.Writer, credentials cstate.Credentials) {
+	var name string
+	for _, cred := range credentials {
+		if cred.Name != name {
+			name = cred.Name
+			fmt.Fprintf(w, "%s\n", pathHeader(cred))
+		}
+		fmt.Fprintf(w, "  - %s | %s | deployments: %s\n",
+			versionLabel(cred), cred.Summary(), deploymentsOrNone(cred.Deployments))
+	}
+}
+
+func pathHeader(cred *cstate.Credential) string {
+	if cred.Type == ccredhub.Certificate && cred.CertificateAuthority {
+		return fmt.Sprintf("%s (phase: %s)", cred.Name, cred.Path.Phase())
+	}
+	return cred.Name
+}
+
+func versionLabel(cred *cstate.Credential) string {
+	flags := make([]string, 0)
+	if cred.Latest {
+		flags = append(flags, "latest")
+	}
+	if cred.Transitional {
+		flags = append(flags, "transitional")
+	}
+	if cred.Signing != nil && *cred.Signing {
+		flags = append(flags, "signing")
+	}
+	if len(flags) == 0 {
+		return cred.ID
+	}
+	return fmt.Sprintf("%s (%s)", cred.ID, strings.Join(flags, ", "))
+}
+
+func deploymentsOrNone(d cstate.Deployments) string {
+	if len(d) == 0 {
+		return "none"
+	}
+	return d.String()
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	ccredhub "github.com/cloudfoundry-community/carousel/credhub"
+	cstate "github.com/cloudfoundry-community/carousel/state"
+)
+
+// caPath returns the versions of a certificate authority path, newest first,
+// the newest one transitional and not yet deployed.
+func caPath(name string) cstate.Credentials {
+	createdAt := time.Now()
+	yes, no := true, false
+	path := &cstate.Path{Name: name}
+	newCA := &cstate.Credential{
+		Credential: &ccredhub.Credential{ID: "ca-v2", Name: name, Type: ccredhub.Certificate,
+			VersionCreatedAt: &createdAt, ExpiryDate: &createdAt},
+		Latest: true, Signing: &no, Path: path,
+	}
+	newCA.Transitional = true
+	newCA.CertificateAuthority = true
+	oldCA := &cstate.Credential{
+		Credential: &ccredhub.Credential{ID: "ca-v1", Name: name, Type: ccredhub.Certificate,
+			VersionCreatedAt: &createdAt, ExpiryDate: &createdAt},
+		Signing: &yes, Path: path, Deployments: cstate.Deployments{{Name: "cf"}},
+	}
+	oldCA.CertificateAuthority = true
+	path.Versions = cstate.Credentials{newCA, oldCA}
+	path.Deployments = oldCA.Deployments
+	return path.Versions
+}
+
+var _ = Describe("renderList", func() {
+	It("shows the rotation phase of certificate authority paths", func() {
+		var out bytes.Buffer
+		renderList(&out, caPath("/bosh/cf/ca"))
+		Expect(out.String()).To(HavePrefix("/bosh/cf/ca (phase: staged)\n"))
+		Expect(out.String()).To(ContainSubstring("  - ca-v2 (latest, transitional) | "))
+		Expect(out.String()).To(ContainSubstring("  - ca-v1 (signing) | "))
+		Expect(out.String()).To(ContainSubstring("| deployments: cf\n"))
+	})
+})
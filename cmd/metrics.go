@@ -0,0 +1,80 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	ccredhub "github.com/cloudfoundry-community/carousel/credhub"
+	cstate "github.com/cloudfoundry-community/carousel/state"
+)
+
+// metricsCmd represents the metrics command
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Print rotation metrics in the Prometheus text format",
+	Long: `Prints the rotation phase of every certificate authority path in the
+Prometheus text exposition format, e.g. for the textfile collector of the
+node exporter:
+
+  carousel metrics > carousel.prom.tmp && mv carousel.prom.tmp carousel.prom
+
+Each path has a carousel_rotation_phase series per phase, the one of its
+current phase is 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		initialize()
+		refresh()
+
+		credentials := state.Credentials(append(filters.Filters(),
+			cstate.TypeFilter(ccredhub.Certificate),
+			cstate.CertificateAuthorityFilter(true),
+			cstate.LatestFilter(),
+		)...)
+		credentials.SortByNameAndCreatedAt()
+
+		renderPhaseMetrics(cmd.OutOrStdout(), credentials)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(metricsCmd)
+
+	addDeploymentFlag(metricsCmd.Flags())
+	addPathFlags(metricsCmd.Flags())
+	addWhereFlag(metricsCmd.Flags())
+}
+
+// renderPhaseMetrics prints the rotation phase of the path of each of
+// credentials as a Prometheus state set.
+func renderPhaseMetrics(w io.Writer, credentials cstate.Credentials) {
+	fmt.Fprintf(w, "# HELP carousel_rotation_phase Rotation phase of a certificate authority path.\n")
+	fmt.Fprintf(w, "# TYPE carousel_rotation_phase gauge\n")
+	for _, cred := range credentials {
+		current := cred.Path.Phase()
+		for _, phase := range cstate.RotationPhaseValues() {
+			value := 0
+			if phase == current {
+				value = 1
+			}
+			fmt.Fprintf(w, "carousel_rotation_phase{path=%s,phase=%s} %d\n",
+				strconv.Quote(cred.Name), strconv.Quote(phase.String()), value)
+		}
+	}
+}
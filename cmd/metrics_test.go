@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("renderPhaseMetrics", func() {
+	It("sets the series of the current phase of each path", func() {
+		var out bytes.Buffer
+		renderPhaseMetrics(&out, caPath("/bosh/cf/ca")[:1])
+		Expect(out.String()).To(ContainSubstring("# TYPE carousel_rotation_phase gauge\n"))
+		Expect(out.String()).To(ContainSubstring(
+			`carousel_rotation_phase{path="/bosh/cf/ca",phase="staged"} 1` + "\n"))
+		Expect(out.String()).To(ContainSubstring(
+			`carousel_rotation_phase{path="/bosh/cf/ca",phase="idle"} 0` + "\n"))
+	})
+})
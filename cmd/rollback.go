@@ -0,0 +1,102 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	cstate "github.com/cloudfoundry-community/carousel/state"
+)
+
+var rollbackVersion string
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <path>",
+	Short: "Restore a previous version of a credential as its latest version",
+	Long: `Sets the value of a previous version (the one before the latest by default)
+as a new latest version, e.g. when a regenerated credential breaks a deployment.
+
+For certificate authorities the rolled back version stays transitional while
+credentials it signed are still in use, so they keep being trusted until they
+are rolled back or regenerated too. Otherwise the transitional flag is removed.
+
+Note that CredHub treats a restored value as set instead of generated.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		initialize()
+		refresh()
+
+		cred, found := state.Credentials(cstate.NameFilter(args[0])).Find(cstate.LatestFilter())
+		if !found {
+			logger.Fatalf("credential not found: %s", args[0])
+		}
+
+		rollback, err := cred.Path.Rollback(rollbackVersion)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		cmd.Printf("Roll back %s\n  L %s\nto %s\n  L %s\n",
+			rollback.Current.PathVersion(), rollback.Current.Summary(),
+			rollback.Target.PathVersion(), rollback.Target.Summary())
+		if rollback.UpdateTransitional {
+			if rollback.Transitional != nil {
+				cmd.Printf("and mark %s transitional, it still signs credentials in use\n",
+					rollback.Transitional.PathVersion())
+			} else {
+				cmd.Printf("and remove the transitional flag of %s\n", rollback.Path.Name)
+			}
+		}
+		cmd.Println("")
+
+		askForConfirmation()
+
+		cmd.Printf("\nRestoring %s", rollback.Target.PathVersion())
+		if err := credhub.Copy(rollback.Target.Credential, rollback.Path.Name); err != nil {
+			cmd.Printf(" got error: %s\n", err)
+			os.Exit(1)
+		}
+		cmd.Print(" done\n")
+
+		if rollback.UpdateTransitional {
+			transitional, remove := rollback.Transitional, rollback.Transitional == nil
+			if remove {
+				transitional = rollback.Current
+			}
+			cmd.Printf("Updating transitional version of %s", rollback.Path.Name)
+			if err := credhub.UpdateTransitional(transitional.Credential, remove); err != nil {
+				cmd.Printf(" got error: %s\n", err)
+				os.Exit(1)
+			}
+			cmd.Print(" done\n")
+		}
+		cmd.Println("")
+
+		if len(rollback.Deployments) != 0 {
+			cmd.Printf("Deployment(s) needing a bosh deploy: %s\n", rollback.Deployments.String())
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+
+	rollbackCmd.Flags().StringVar(&rollbackVersion, "version", "",
+		"id of the version to restore (default the version before the latest)")
+}
@@ -16,6 +16,8 @@ limitations under the License.
 package cmd
 
 import (
+	"os"
+
 	"github.com/spf13/cobra"
 )
 
@@ -34,20 +36,42 @@ CREDHUB_SERVER      CredHub URL of API server to target
 CREDHUB_CLIENT      CredHub UAA client
 CREDHUB_SECRET      CredHub UAA client secret
 CREDHUB_CA_CERT     CredHub & UAA CA certificate value
+
+BOSH_ENVIRONMENT may also be an alias, in which case the URL, CA certificate
+and client credentials are looked up in the bosh cli config file (BOSH_CONFIG,
+defaults to ~/.bosh/config). Likewise missing CREDHUB_* values are read from
+the credhub cli config file (~/.credhub/config.json). Environment variables
+always take precedence over the config files.
+
+The rules deciding what to do with each credential can be tuned using a
+policy file given with --policy or CAROUSEL_POLICY. Changes to CredHub are
+recorded in the audit log given with --audit-log or CAROUSEL_AUDIT_LOG.
 `,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		commandPath = cmd.CommandPath()
+	},
 }
 
 func Execute() {
 	cobra.CheckErr(rootCmd.Execute())
 }
 
-var nonInteractive bool
+var (
+	nonInteractive bool
+	policyFile     string
+	auditLogFile   string
+	commandPath    string
+)
 
 func init() {
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 	rootCmd.PersistentFlags().BoolVarP(&nonInteractive, "non-interactive", "n", false, "Don't ask for user input")
+	rootCmd.PersistentFlags().StringVar(&policyFile, "policy", os.Getenv("CAROUSEL_POLICY"),
+		"policy file tuning the rules deciding actions (env: CAROUSEL_POLICY)")
+	rootCmd.PersistentFlags().StringVar(&auditLogFile, "audit-log", os.Getenv("CAROUSEL_AUDIT_LOG"),
+		"append an entry for each change to CredHub to this file (env: CAROUSEL_AUDIT_LOG)")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -16,13 +16,26 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"sort"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/cloudfoundry-community/carousel/hooks"
+	cpolicy "github.com/cloudfoundry-community/carousel/policy"
 	cstate "github.com/cloudfoundry-community/carousel/state"
 )
 
+var (
+	rotateVerbose bool
+	rotatePlanOut string
+	rotatePlanIn  string
+)
+
 // statusCmd represents the status command
 var rotateCmd = &cobra.Command{
 	Use:   "rotate",
@@ -31,24 +44,46 @@ var rotateCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		initialize()
 
+		if rotatePlanIn != "" {
+			runPlan(cmd)
+			return
+		}
+
 		regenerationCriteria, err := criteria.RegenerationCriteria()
 		if err != nil {
 			logger.Fatal(err)
 		}
 
-		var credentialsToDeploy, credentialsToAction cstate.Credentials
+		schedule, err := policy.Schedule()
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		rollout := criteria.Rollout(policy.Rollout).Run()
+
+		var credentialsToDeploy, credentialsToAction, credentialsDeferred, credentialsHeld cstate.Credentials
+		heldBecause := make(map[string]string)
+		vetoedBecause := make(map[string]string)
+		phases := make(map[string]cstate.RotationPhase)
 
-		for {
+		for round := 1; ; round++ {
 			cmd.Printf("Refreshing state")
 			refresh()
 			cmd.Printf(" done\n\n")
 
 			credentialsToAction = make(cstate.Credentials, 0)
 			credentialsToDeploy = make(cstate.Credentials, 0)
+			credentialsDeferred = make(cstate.Credentials, 0)
+			credentialsHeld = make(cstate.Credentials, 0)
+			candidates := make(cstate.Credentials, 0)
+			now := criteria.Now()
+			rollout.NextRound()
 
 			credentials := state.Credentials(filters.Filters()...)
 			credentials.SortByNameAndCreatedAt()
 
+			phases = checkPhases(cmd, credentials, phases)
+
 			for _, cred := range credentials {
 				switch action := cred.NextAction(regenerationCriteria); {
 				case action == cstate.BoshDeploy:
@@ -57,79 +92,332 @@ var rotateCmd = &cobra.Command{
 					continue
 				case action == cstate.None:
 					continue
+				case !schedule.Allowed(cred.Name, cred.Path.Deployments.Names(), now):
+					credentialsDeferred = append(credentialsDeferred, cred)
 				default:
-					credentialsToAction = append(credentialsToAction, cred)
+					candidates = append(candidates, cred)
 				}
 			}
 
+			for _, cred := range candidates {
+				if _, vetoed := vetoedBecause[cred.ID]; vetoed {
+					continue
+				}
+				if admitted, reason := rollout.Admit(cred, credentialsToDeploy); !admitted {
+					credentialsHeld = append(credentialsHeld, cred)
+					heldBecause[cred.ID] = reason
+					continue
+				}
+				credentialsToAction = append(credentialsToAction, cred)
+			}
+
 			if len(credentialsToAction) == 0 {
 				cmd.Printf("No further actions to perform\n\n")
 				break
+			} else if rotatePlanOut != "" {
+				writePlan(cmd, credentialsToAction, func(cred *cstate.Credential) cstate.Action {
+					return cred.NextAction(regenerationCriteria)
+				})
+				break
 			} else {
 				cmd.Printf("Perform actions:\n")
 
 				for _, cred := range credentialsToAction {
 					cmd.Printf("- %s %s\n  L %s\n",
 						cred.NextAction(regenerationCriteria).String(), cred.PathVersion(), cred.Summary())
+					if rotateVerbose {
+						printDecision(cmd, cred.Decide(regenerationCriteria), "    ")
+					}
 				}
 
 				askForConfirmation()
 
-				cmd.Printf("\nPerforming actions:\n")
-
+				actions := make([]cstate.Action, 0, len(credentialsToAction))
 				for _, cred := range credentialsToAction {
-					action := cred.NextAction(regenerationCriteria)
-					cmd.Printf("- %s %s",
-						action.String(), cred.PathVersion())
-					switch action {
-					case cstate.Regenerate:
-						err := credhub.ReGenerate(cred.Credential)
-						if err != nil {
-							cmd.Printf(" got error: %s\n", err)
-							os.Exit(1)
-						}
-					case cstate.MarkTransitional:
-						err := credhub.UpdateTransitional(cred.Credential, false)
-						if err != nil {
-							cmd.Printf(" got error: %s\n", err)
-							os.Exit(1)
-						}
-					case cstate.UnMarkTransitional:
-						err := credhub.UpdateTransitional(cred.Credential, true)
-						if err != nil {
-							cmd.Printf(" got error: %s\n", err)
-							os.Exit(1)
-						}
-					case cstate.CleanUp:
-						err := credhub.Delete(cred.Credential)
-						if err != nil {
-							cmd.Printf(" got error: %s\n", err)
-							os.Exit(1)
-						}
-					}
-					cmd.Print(" done\n")
+					actions = append(actions, cred.NextAction(regenerationCriteria))
+				}
+				if !performRound(cmd, round, credentialsToAction, actions, vetoedBecause) {
+					break
+				}
+			}
+		}
+
+		if len(credentialsDeferred) != 0 {
+			now := criteria.Now()
+			cmd.Printf("Deferred action(s) outside of maintenance windows:\n")
+			for _, cred := range credentialsDeferred {
+				cmd.Printf("- %s %s (%s)\n  L %s\n",
+					cred.NextAction(regenerationCriteria).String(), cred.PathVersion(),
+					nextWindow(schedule, cred, cred.Path.Deployments, now), cred.Summary())
+				if rotateVerbose {
+					printDecision(cmd, cred.Decide(regenerationCriteria), "    ")
+				}
+			}
+			cmd.Println("")
+		}
+
+		if len(vetoedBecause) != 0 {
+			cmd.Printf("Vetoed action(s):\n")
+			for _, cred := range state.Credentials(filters.Filters()...) {
+				if reason, vetoed := vetoedBecause[cred.ID]; vetoed {
+					cmd.Printf("- %s %s (%s)\n  L %s\n",
+						cred.NextAction(regenerationCriteria).String(), cred.PathVersion(), reason, cred.Summary())
 				}
-				cmd.Println("")
 			}
+			cmd.Println("")
+		}
+
+		if len(credentialsHeld) != 0 {
+			cmd.Printf("Held back action(s) to stage the rollout:\n")
+			for _, cred := range credentialsHeld {
+				cmd.Printf("- %s %s (%s)\n  L %s\n",
+					cred.NextAction(regenerationCriteria).String(), cred.PathVersion(),
+					heldBecause[cred.ID], cred.Summary())
+				if rotateVerbose {
+					printDecision(cmd, cred.Decide(regenerationCriteria), "    ")
+				}
+			}
+			cmd.Println("")
+		}
+
+		flagged := state.Credentials(append(filters.Filters(),
+			cstate.LatestFilter(), cstate.FlaggedIssuerFilter())...)
+		if len(flagged) != 0 {
+			cmd.Printf("Skipped certificate(s) with an ambiguous or unverified issuer:\n")
+			for _, cred := range flagged {
+				cmd.Printf("- %s (issuer %s)\n  L %s\n",
+					cred.PathVersion(), cred.IssuerStatus, cred.Summary())
+			}
+			cmd.Println("")
 		}
 
+		printDangling(cmd, state.Deployments().Dangling().Select(filters.selectsDeployment))
+
 		if len(credentialsToDeploy) != 0 {
 			cmd.Printf("Found credential(s) pending a bosh deploy:\n")
 			for _, cred := range credentialsToDeploy {
-				cmd.Printf("- bosh_deploy(%s) %s\n  L %s\n",
-					cred.PendingDeploys().String(), cred.PathVersion(), cred.Summary())
+				window := ""
+				if now := criteria.Now(); !schedule.Allowed(cred.Name, cred.PendingDeploys().Names(), now) {
+					window = fmt.Sprintf(" (%s)", nextWindow(schedule, cred, cred.PendingDeploys(), now))
+				}
+				cmd.Printf("- bosh_deploy(%s) %s%s\n  L %s\n",
+					cred.PendingDeploys().String(), cred.PathVersion(), window, cred.Summary())
+				if rotateVerbose {
+					printDecision(cmd, cred.Decide(regenerationCriteria), "    ")
+				}
 			}
 		}
 	},
 }
 
+// performAction carries out action on cred and exits on failure, unless a
+// before_action hook vetoes it, which is returned as error.
+func performAction(cmd *cobra.Command, cred *cstate.Credential, action cstate.Action) error {
+	cmd.Printf("- %s %s",
+		action.String(), cred.PathVersion())
+	if err := runner.BeforeAction(cred, action); err != nil {
+		cmd.Printf(" skipped: %s\n", err)
+		return err
+	}
+	var err error
+	switch action {
+	case cstate.Regenerate:
+		err = credhub.ReGenerate(cred.Credential)
+	case cstate.MarkTransitional:
+		err = credhub.UpdateTransitional(cred.Credential, false)
+	case cstate.UnMarkTransitional:
+		err = credhub.UpdateTransitional(cred.Credential, true)
+	case cstate.StagePrevious, cstate.RetirePrevious:
+		companion := cstate.CompanionName(cred.Name, policy.DualCredentials.CompanionSuffix())
+		err = credhub.Copy(cred.Credential, companion)
+	case cstate.CleanUp:
+		err = credhub.Delete(cred.Credential)
+	}
+	hookErr := runner.AfterAction(cred, action, err)
+	if err != nil {
+		cmd.Printf(" got error: %s\n", err)
+		os.Exit(1)
+	}
+	cmd.Print(" done\n")
+	if hookErr != nil {
+		cmd.Printf("  L warning: %s\n", hookErr)
+	}
+	return nil
+}
+
+// writePlan writes the actions decided for credentials to --plan-out,
+// instead of carrying them out.
+func writePlan(cmd *cobra.Command, credentials cstate.Credentials, action func(*cstate.Credential) cstate.Action) {
+	plan := cstate.NewPlan(credentials, action, time.Now())
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		logger.Fatalf("failed to encode plan: %s", err)
+	}
+	if err := ioutil.WriteFile(rotatePlanOut, data, 0644); err != nil {
+		logger.Fatalf("failed to write plan: %s got: %s", rotatePlanOut, err)
+	}
+	cmd.Printf("Planned actions:\n")
+	for _, cred := range credentials {
+		cmd.Printf("- %s %s\n  L %s\n", action(cred).String(), cred.PathVersion(), cred.Summary())
+	}
+	cmd.Printf("\nWrote plan with %d action(s) to %s (fingerprint %s)\n\n",
+		len(plan.Actions), rotatePlanOut, plan.Fingerprint)
+}
+
+// runPlan carries out exactly the actions of --plan-in, as long as the state
+// still matches the plan and the maintenance windows allow it.
+func runPlan(cmd *cobra.Command) {
+	data, err := ioutil.ReadFile(rotatePlanIn)
+	if err != nil {
+		logger.Fatalf("failed to read plan: %s got: %s", rotatePlanIn, err)
+	}
+	var plan cstate.Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		logger.Fatalf("failed to parse plan: %s got: %s", rotatePlanIn, err)
+	}
+
+	schedule, err := policy.Schedule()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	cmd.Printf("Refreshing state")
+	refresh()
+	cmd.Printf(" done\n\n")
+
+	credentials, err := plan.Resolve(state.Credentials())
+	if err != nil {
+		logger.Fatalf("refusing to carry out plan %s: %s", rotatePlanIn, err)
+	}
+
+	now := criteria.Now()
+	cmd.Printf("Perform planned actions (planned at %s):\n", plan.CreatedAt.Format(time.RFC3339))
+	for i, cred := range credentials {
+		cmd.Printf("- %s %s\n  L %s\n", plan.Actions[i].Action.String(), cred.PathVersion(), cred.Summary())
+		if !schedule.Allowed(cred.Name, cred.Path.Deployments.Names(), now) {
+			logger.Fatalf("refusing to carry out plan %s: %s is outside of maintenance windows (%s)",
+				rotatePlanIn, cred.PathVersion(), nextWindow(schedule, cred, cred.Path.Deployments, now))
+		}
+	}
+
+	askForConfirmation()
+
+	actions := make([]cstate.Action, 0, len(plan.Actions))
+	for _, a := range plan.Actions {
+		actions = append(actions, a.Action)
+	}
+	performRound(cmd, 1, credentials, actions, make(map[string]string))
+}
+
+// performRound carries out a round of actions, after which the affected
+// deployments need a bosh deploy, and runs the deploy round hooks around it.
+// Actions vetoed by a hook are recorded in vetoed, it returns false when a
+// hook vetoed the whole round.
+func performRound(cmd *cobra.Command, round int, credentials cstate.Credentials, actions []cstate.Action,
+	vetoed map[string]string) bool {
+	roundActions := make([]hooks.RoundAction, 0, len(credentials))
+	for i, cred := range credentials {
+		roundActions = append(roundActions,
+			hooks.RoundAction{Action: actions[i].String(), Name: cred.Name, ID: cred.ID})
+	}
+	deployments := roundDeployments(credentials)
+
+	if err := runner.BeforeDeployRound(round, roundActions, deployments); err != nil {
+		cmd.Printf("\nSkipping deploy round %d: %s\n\n", round, err)
+		return false
+	}
+
+	cmd.Printf("\nPerforming actions:\n")
+	for i, cred := range credentials {
+		if err := performAction(cmd, cred, actions[i]); err != nil {
+			vetoed[cred.ID] = err.Error()
+		}
+	}
+	cmd.Println("")
+
+	if err := runner.AfterDeployRound(round, roundActions, deployments); err != nil {
+		cmd.Printf("Warning: %s\n\n", err)
+	}
+	return true
+}
+
+// roundDeployments returns the sorted names of the deployments using the
+// paths of credentials.
+func roundDeployments(credentials cstate.Credentials) []string {
+	seen := make(map[string]bool)
+	out := make([]string, 0)
+	for _, cred := range credentials {
+		for _, name := range cred.Path.Deployments.Names() {
+			if !seen[name] {
+				seen[name] = true
+				out = append(out, name)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// nextWindow describes when the next maintenance window for changing cred
+// used by deployments opens.
+func nextWindow(schedule cpolicy.Schedule, cred *cstate.Credential, deployments cstate.Deployments,
+	now time.Time) string {
+	next, found := schedule.NextOpen(cred.Name, deployments.Names(), now)
+	if !found {
+		return "no maintenance window opens"
+	}
+	return fmt.Sprintf("next maintenance window opens %s", next.Format("2006-01-02 15:04 MST"))
+}
+
+// checkPhases prints the rotation phase of every certificate authority path
+// being rotated and exits when a phase moved in a way a rotation never does
+// since the previous refresh, as that means the state was changed by hand.
+func checkPhases(cmd *cobra.Command, credentials cstate.Credentials,
+	previous map[string]cstate.RotationPhase) map[string]cstate.RotationPhase {
+	current := make(map[string]cstate.RotationPhase)
+	for _, cred := range credentials {
+		if !cred.Latest {
+			continue
+		}
+		phase := cred.Path.Phase()
+		if err := cstate.ValidatePhaseTransition(previous[cred.Name], phase); err != nil {
+			cmd.Printf("Rotation of %s changed unexpectedly: %s\n", cred.Name, err)
+			os.Exit(1)
+		}
+		if phase != cstate.PhaseIdle {
+			current[cred.Name] = phase
+		}
+	}
+
+	if len(current) != 0 {
+		cmd.Printf("Rotation phases:\n")
+		for _, cred := range credentials {
+			if phase, found := current[cred.Name]; found && cred.Latest {
+				cmd.Printf("- %s %s\n", cred.Name, phase)
+			}
+		}
+		cmd.Println("")
+	}
+	return current
+}
+
 func init() {
 	rootCmd.AddCommand(rotateCmd)
 
 	addExpiresWithinCriteriaFlag(rotateCmd.Flags())
 	addOlderThanCireteriaFlag(rotateCmd.Flags())
 	addIgnoreUpdateModeCireteriaFlag(rotateCmd.Flags())
+	addNowFlag(rotateCmd.Flags())
+	addRolloutFlags(rotateCmd.Flags())
+	rotateCmd.Flags().BoolVarP(&rotateVerbose, "verbose", "v", false,
+		"print the rule deciding each action and the values it compared")
+	rotateCmd.Flags().StringVar(&rotatePlanOut, "plan-out", "",
+		"write the next actions with a fingerprint of the state to this file instead of performing them")
+	rotateCmd.Flags().StringVar(&rotatePlanIn, "plan-in", "",
+		"perform exactly the actions of this plan file, refusing when the state changed since")
 	addNameFlag(rotateCmd.Flags())
 	addDeploymentFlag(rotateCmd.Flags())
+	addPathFlags(rotateCmd.Flags())
+	addWhereFlag(rotateCmd.Flags())
 	addTypesFlag(rotateCmd.Flags())
 }
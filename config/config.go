@@ -1,7 +1,20 @@
 package config
 
-import "github.com/kelseyhightower/envconfig"
-import "strings"
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/kelseyhightower/envconfig"
+
+	chconfig "code.cloudfoundry.org/credhub-cli/config"
+	boshconfig "github.com/cloudfoundry/bosh-cli/cmd/config"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
 
 type Config struct {
 	Bosh    *Bosh
@@ -9,34 +22,158 @@ type Config struct {
 }
 
 type Bosh struct {
-	Environment  string `required:"true"`
-	Client       string `required:"true"`
-	ClientSecret string `required:"true" split_words:"true"`
-	CaCert       string `required:"true" split_words:"true"`
+	Environment  string
+	Client       string
+	ClientSecret string `split_words:"true"`
+	CaCert       string `split_words:"true"`
+	Config       string `default:"~/.bosh/config"`
 }
 
 type Credhub struct {
-	Server string `required:"true"`
-	Client string `required:"true"`
-	Secret string `required:"true"`
-	CaCert string `required:"true" split_words:"true"`
+	Server       string
+	Client       string
+	Secret       string
+	CaCert       string `split_words:"true"`
+	AccessToken  string `ignored:"true"`
+	RefreshToken string `ignored:"true"`
+}
+
+// UsesTokens is true when no client credentials are configured and the
+// tokens of a previous `credhub login` should be used instead.
+func (c *Credhub) UsesTokens() bool {
+	return c.Client == "" && c.RefreshToken != ""
 }
 
 func LoadConfig() (*Config, error) {
+	b, err := loadBosh()
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := loadCredhub()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Config{b, c}, nil
+}
+
+// loadBosh reads the BOSH_* environment variables and fills in whatever is
+// missing from the environment entry in the bosh cli config file
+// (BOSH_CONFIG, defaults to ~/.bosh/config) matching BOSH_ENVIRONMENT by
+// URL or alias.
+func loadBosh() (*Bosh, error) {
 	var b Bosh
 	err := envconfig.Process("bosh", &b)
 	if err != nil {
 		return nil, err
 	}
+
+	if b.Environment != "" {
+		fs := boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		file, err := boshconfig.NewFSConfigFromPath(b.Config, fs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bosh config: %s got: %s", b.Config, err)
+		}
+
+		creds := file.Credentials(b.Environment)
+		if b.CaCert == "" {
+			b.CaCert = file.CACert(b.Environment)
+		}
+		if b.Client == "" {
+			b.Client = creds.Client
+		}
+		if b.ClientSecret == "" {
+			b.ClientSecret = creds.ClientSecret
+		}
+		b.Environment = file.ResolveEnvironment(b.Environment)
+	}
+
 	if strings.Contains(b.CaCert, "\\n") {
 		b.CaCert = strings.ReplaceAll(b.CaCert, "\\n", "\n")
 	}
 
+	return &b, requireKeys("bosh", map[string]string{
+		"environment":   b.Environment,
+		"client":        b.Client,
+		"client_secret": b.ClientSecret,
+		"ca_cert":       b.CaCert,
+	})
+}
+
+// loadCredhub reads the CREDHUB_* environment variables and fills in
+// whatever is missing from the credhub cli config file
+// (~/.credhub/config.json). Like the credhub cli the tokens from a previous
+// login are only used when CREDHUB_SERVER has not been set.
+func loadCredhub() (*Credhub, error) {
 	var c Credhub
-	err = envconfig.Process("credhub", &c)
+	err := envconfig.Process("credhub", &c)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := readCredhubConfig(chconfig.ConfigPath())
 	if err != nil {
 		return nil, err
 	}
 
-	return &Config{&b, &c}, nil
+	if c.Server == "" {
+		c.Server = file.ApiURL
+		c.AccessToken = file.AccessToken
+		c.RefreshToken = file.RefreshToken
+	}
+	if c.CaCert == "" {
+		c.CaCert = strings.Join(file.CaCerts, "\n")
+	}
+	if c.Client == "" {
+		c.Client = file.ClientID
+	}
+	if c.Secret == "" {
+		c.Secret = file.ClientSecret
+	}
+
+	if c.UsesTokens() {
+		return &c, requireKeys("credhub", map[string]string{
+			"server": c.Server,
+		})
+	}
+
+	return &c, requireKeys("credhub", map[string]string{
+		"server": c.Server,
+		"client": c.Client,
+		"secret": c.Secret,
+	})
+}
+
+func readCredhubConfig(path string) (chconfig.Config, error) {
+	var c chconfig.Config
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("failed to read credhub config: %s got: %s", path, err)
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("failed to parse credhub config: %s got: %s", path, err)
+	}
+
+	return c, nil
+}
+
+func requireKeys(prefix string, values map[string]string) error {
+	missing := make([]string, 0)
+	for key, value := range values {
+		if value == "" {
+			missing = append(missing, strings.ToUpper(prefix+"_"+key))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	sort.Strings(missing)
+	return fmt.Errorf("required key(s) %s missing value (set them or log in using the %s cli)",
+		strings.Join(missing, ", "), prefix)
 }
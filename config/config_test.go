@@ -0,0 +1,121 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+
+	. "github.com/cloudfoundry-community/carousel/config"
+)
+
+var _ = Describe("LoadConfig", func() {
+	var (
+		home    string
+		oldHome string
+		cfg     *Config
+		err     error
+	)
+
+	envKeys := []string{
+		"BOSH_ENVIRONMENT", "BOSH_CLIENT", "BOSH_CLIENT_SECRET", "BOSH_CA_CERT", "BOSH_CONFIG",
+		"CREDHUB_SERVER", "CREDHUB_CLIENT", "CREDHUB_SECRET", "CREDHUB_CA_CERT",
+	}
+	oldEnv := map[string]string{}
+
+	writeFile := func(name, content string) {
+		path := filepath.Join(home, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(content), 0600)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		home, err = ioutil.TempDir("", "carousel-config-")
+		Expect(err).ToNot(HaveOccurred())
+		oldHome = os.Getenv("HOME")
+		os.Setenv("HOME", home)
+		for _, k := range envKeys {
+			oldEnv[k] = os.Getenv(k)
+			os.Unsetenv(k)
+		}
+
+		writeFile(".bosh/config", `
+environments:
+- url: https://10.0.0.6:25555
+  alias: my-env
+  ca_cert: file-bosh-ca
+  username: file-bosh-client
+  password: file-bosh-secret
+`)
+		writeFile(".credhub/config.json", `{
+  "ApiURL": "https://10.0.0.6:8844",
+  "CaCerts": ["file-credhub-ca"],
+  "AccessToken": "access",
+  "RefreshToken": "refresh"
+}`)
+	})
+
+	AfterEach(func() {
+		os.Setenv("HOME", oldHome)
+		for k, v := range oldEnv {
+			os.Setenv(k, v)
+		}
+		os.RemoveAll(home)
+	})
+
+	JustBeforeEach(func() {
+		cfg, err = LoadConfig()
+	})
+
+	Context("given a bosh environment alias", func() {
+		BeforeEach(func() {
+			os.Setenv("BOSH_ENVIRONMENT", "my-env")
+		})
+
+		It("resolves the environment from the cli config files", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*cfg.Bosh).To(MatchFields(IgnoreExtras, Fields{
+				"Environment":  Equal("https://10.0.0.6:25555"),
+				"Client":       Equal("file-bosh-client"),
+				"ClientSecret": Equal("file-bosh-secret"),
+				"CaCert":       Equal("file-bosh-ca"),
+			}))
+			Expect(cfg.Credhub.Server).To(Equal("https://10.0.0.6:8844"))
+			Expect(cfg.Credhub.CaCert).To(Equal("file-credhub-ca"))
+			Expect(cfg.Credhub.UsesTokens()).To(BeTrue())
+		})
+
+		Context("and environment variables", func() {
+			BeforeEach(func() {
+				os.Setenv("BOSH_CLIENT", "env-bosh-client")
+				os.Setenv("BOSH_CA_CERT", "env-bosh-ca")
+				os.Setenv("CREDHUB_SERVER", "https://credhub.example.com")
+				os.Setenv("CREDHUB_CLIENT", "env-credhub-client")
+				os.Setenv("CREDHUB_SECRET", "env-credhub-secret")
+			})
+
+			It("lets the environment variables take precedence", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cfg.Bosh.Client).To(Equal("env-bosh-client"))
+				Expect(cfg.Bosh.ClientSecret).To(Equal("file-bosh-secret"))
+				Expect(cfg.Bosh.CaCert).To(Equal("env-bosh-ca"))
+				Expect(cfg.Credhub.Server).To(Equal("https://credhub.example.com"))
+				Expect(cfg.Credhub.RefreshToken).To(BeEmpty())
+				Expect(cfg.Credhub.UsesTokens()).To(BeFalse())
+			})
+		})
+	})
+
+	Context("given an unknown bosh environment", func() {
+		BeforeEach(func() {
+			os.Setenv("BOSH_ENVIRONMENT", "https://10.0.0.7:25555")
+		})
+
+		It("reports the missing keys", func() {
+			Expect(err).To(MatchError(ContainSubstring("BOSH_CA_CERT, BOSH_CLIENT, BOSH_CLIENT_SECRET")))
+		})
+	})
+})
@@ -8,13 +8,16 @@ import (
 	"sync"
 
 	chcli "code.cloudfoundry.org/credhub-cli/credhub"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/values"
 )
 
 type CredHub interface {
 	FindAll() ([]*Credential, error)
+	FindLatest(name string) (*Credential, error)
 	ReGenerate(cred *Credential) error
 	Delete(cred *Credential) error
 	UpdateTransitional(cred *Credential, remove bool) error
+	Copy(cred *Credential, name string) error
 }
 
 func NewCredHub(ch *chcli.CredHub) CredHub {
@@ -57,6 +60,27 @@ func (ch *credhub) FindAll() ([]*Credential, error) {
 	return ch.getAllVersionsForAllPaths(keys)
 }
 
+// FindLatest returns the latest version of the credential name.
+func (ch *credhub) FindLatest(name string) (*Credential, error) {
+	resp, err := ch.client.Request(http.MethodGet, "/api/v1/data",
+		url.Values{"name": []string{name}, "current": []string{"true"}}, nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed request got: %s", err)
+	}
+	defer resp.Body.Close()
+
+	result := struct {
+		Data []*Credential `json:"data"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if len(result.Data) == 0 {
+		return nil, fmt.Errorf("credential not found: %s", name)
+	}
+	return result.Data[0], nil
+}
+
 func (ch *credhub) Delete(c *Credential) error {
 	switch c.Type {
 	case Certificate:
@@ -127,6 +151,38 @@ func (ch *credhub) UpdateTransitional(c *Credential, remove bool) error {
 	return nil
 }
 
+// Copy sets the value of cred as a new version of the credential name,
+// which is created when it does not exist yet.
+func (ch *credhub) Copy(c *Credential, name string) error {
+	var err error
+	switch c.Type {
+	case Password:
+		_, err = ch.client.SetPassword(name, values.Password(c.Password))
+	case User:
+		_, err = ch.client.SetUser(name, values.User{Username: c.Username, Password: c.Password})
+	case Certificate:
+		_, err = ch.client.SetCertificate(name, values.Certificate{
+			Ca:          c.PEMCa,
+			Certificate: c.PEMCertificate,
+			PrivateKey:  c.PrivateKey,
+		})
+	case SSH:
+		_, err = ch.client.SetSSH(name, values.SSH{PublicKey: c.PublicKey, PrivateKey: c.PrivateKey})
+	case RSA:
+		_, err = ch.client.SetRSA(name, values.RSA{PublicKey: c.PublicKey, PrivateKey: c.PrivateKey})
+	case Value:
+		_, err = ch.client.SetValue(name, values.Value(c.Value))
+	case JSON:
+		_, err = ch.client.SetJSON(name, values.JSON(c.JSON))
+	default:
+		return fmt.Errorf("Copying a credential not supported for type: %s", c.Type.String())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set: %s got: %s", name, err)
+	}
+	return nil
+}
+
 func (ch *credhub) getAllVersions(path string) ([]*Credential, error) {
 	resp, err := ch.client.Request(http.MethodGet, "/api/v1/data",
 		url.Values{"name": []string{path}}, nil, true)
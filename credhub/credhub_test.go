@@ -184,4 +184,40 @@ var _ = Describe("Credhub", func() {
 			})
 		})
 	})
+
+	Describe("FindLatest", func() {
+		It("finds the latest version", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/data", "name=/some-password&current=true"),
+					ghttp.RespondWith(http.StatusOK, `{
+	"data" : [ {
+		"type" : "password",
+		"version_created_at" : "2020-02-01T20:37:52Z",
+		"id" : "eaebb03f-21a9-41f7-beb0-af4c60aa38d6",
+		"name" : "/some-password",
+		"value" : "secret"
+	}]
+}`),
+				),
+			)
+
+			cred, err := credhub.FindLatest("/some-password")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cred.ID).To(Equal("eaebb03f-21a9-41f7-beb0-af4c60aa38d6"))
+			Expect(cred.Password).To(Equal("secret"))
+		})
+
+		It("returns an error for missing credentials", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/data"),
+					ghttp.RespondWith(http.StatusOK, `{"data": []}`),
+				),
+			)
+
+			_, err := credhub.FindLatest("/missing")
+			Expect(err).To(MatchError("credential not found: /missing"))
+		})
+	})
 })
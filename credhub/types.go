@@ -44,18 +44,18 @@ type Credential struct {
 	Transitional         bool            `json:"transitional,omitempty"`
 	RawValue             json.RawMessage `json:"value,omitempty"`
 
-	Ca                   []*x509.Certificate    `json:"_"`
-	PEMCa                string                 `json:"_"`
-	Certificate          *x509.Certificate      `json:"_"`
-	PEMCertificate       string                 `json:"_"`
-	PrivateKey           string                 `json:"_"`
-	PublicKey            string                 `json:"_"`
-	PublicKeyFingerprint string                 `json:"_"`
-	Password             string                 `json:"_"`
-	PasswordHash         string                 `json:"_"`
-	Username             string                 `json:"_"`
-	JSON                 map[string]interface{} `json:"_"`
-	Value                string                 `json:"_"`
+	Ca                   []*x509.Certificate    `json:"-"`
+	PEMCa                string                 `json:"-"`
+	Certificate          *x509.Certificate      `json:"-"`
+	PEMCertificate       string                 `json:"-"`
+	PrivateKey           string                 `json:"-"`
+	PublicKey            string                 `json:"-"`
+	PublicKeyFingerprint string                 `json:"-"`
+	Password             string                 `json:"-"`
+	PasswordHash         string                 `json:"-"`
+	Username             string                 `json:"-"`
+	JSON                 map[string]interface{} `json:"-"`
+	Value                string                 `json:"-"`
 }
 
 type Metadata map[string]string
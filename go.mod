@@ -9,7 +9,6 @@ require (
 	github.com/cloudfoundry/bosh-cli v6.4.1+incompatible
 	github.com/cloudfoundry/bosh-utils v0.0.0-20210130100352-ab14c90ad9f2
 	github.com/dustin/go-humanize v1.0.0
-	github.com/emirpasic/gods v1.12.0
 	github.com/gdamore/tcell/v2 v2.1.0
 	github.com/gonvenience/ytbx v1.4.0
 	github.com/grantae/certinfo v0.0.0-20170412194111-59d56a35515b
@@ -23,6 +22,7 @@ require (
 	github.com/spf13/cobra v1.1.3
 	github.com/spf13/pflag v1.0.5
 	github.com/vito/go-interact v1.0.0
+	golang.org/x/crypto v0.18.0
 	gopkg.in/yaml.v2 v2.4.0
 	gopkg.in/yaml.v3 v3.0.1
 )
@@ -64,7 +64,6 @@ require (
 	github.com/sergi/go-diff v1.1.0 // indirect
 	github.com/texttheater/golang-levenshtein v1.0.1 // indirect
 	github.com/virtuald/go-ordered-json v0.0.0-20170621173500-b18e6e673d74 // indirect
-	golang.org/x/crypto v0.18.0 // indirect
 	golang.org/x/mod v0.14.0 // indirect
 	golang.org/x/net v0.20.0 // indirect
 	golang.org/x/sync v0.6.0 // indirect
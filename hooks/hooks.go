@@ -0,0 +1,268 @@
+// Package hooks runs the exec hooks and webhooks configured in the policy
+// before and after each action and deploy round of a rotation, e.g.
+//
+//	hooks:
+//	- name: announce-transitional
+//	  events: [after_action]
+//	  actions: [MarkTransitional]
+//	  webhook: https://chat.example.com/hooks/carousel
+//	- name: ticket
+//	  events: [before_action]
+//	  actions: [CleanUp]
+//	  exec: [/usr/local/bin/open-ticket]
+//
+// Hooks receive the context of the event as JSON, exec hooks on stdin and
+// webhooks as body of a POST request. A hook failing on a before event, by
+// exiting non-zero or responding with a non 2xx status, vetoes the action or
+// deploy round.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-community/carousel/query"
+	"github.com/cloudfoundry-community/carousel/state"
+)
+
+type Event string
+
+const (
+	BeforeAction      Event = "before_action"
+	AfterAction       Event = "after_action"
+	BeforeDeployRound Event = "before_deploy_round"
+	AfterDeployRound  Event = "after_deploy_round"
+)
+
+var events = []Event{BeforeAction, AfterAction, BeforeDeployRound, AfterDeployRound}
+
+func (e Event) before() bool {
+	return e == BeforeAction || e == BeforeDeployRound
+}
+
+type Hook struct {
+	Name   string   `yaml:"name"`
+	Events []string `yaml:"events"`
+	// Actions and Where select the credentials of action events, all of
+	// them when empty
+	Actions []string `yaml:"actions,omitempty"`
+	Where   string   `yaml:"where,omitempty"`
+	Exec    []string `yaml:"exec,omitempty"`
+	Webhook string   `yaml:"webhook,omitempty"`
+	// Timeout defaults to 1m
+	Timeout string `yaml:"timeout,omitempty"`
+}
+
+// Context is passed to hooks as JSON.
+type Context struct {
+	Event   Event  `json:"event"`
+	Hook    string `json:"hook"`
+	Command string `json:"command,omitempty"`
+	// Action and Credential are set for action events, Error after an
+	// action failed
+	Action     string            `json:"action,omitempty"`
+	Credential *state.Credential `json:"credential,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	// Round, Actions and Deployments are set for deploy round events, the
+	// deployments need a bosh deploy after the round
+	Round       int           `json:"round,omitempty"`
+	Actions     []RoundAction `json:"actions,omitempty"`
+	Deployments []string      `json:"deployments,omitempty"`
+}
+
+type RoundAction struct {
+	Action string `json:"action"`
+	Name   string `json:"name"`
+	ID     string `json:"id"`
+}
+
+// Runner runs the hooks configured for each event.
+type Runner struct {
+	hooks []*hook
+	// Command is passed in the context of each hook
+	Command string
+	// Output receives the output of exec hooks
+	Output io.Writer
+	client *http.Client
+}
+
+type hook struct {
+	Hook
+	events  map[Event]bool
+	actions map[state.Action]bool
+	filter  state.Filter
+	timeout time.Duration
+}
+
+// NewRunner validates hooks and returns a runner for them.
+func NewRunner(hooks []Hook) (*Runner, error) {
+	out := &Runner{Output: ioutil.Discard, client: &http.Client{}}
+	for _, h := range hooks {
+		compiled, err := compile(h)
+		if err != nil {
+			return nil, err
+		}
+		out.hooks = append(out.hooks, compiled)
+	}
+	return out, nil
+}
+
+func compile(h Hook) (*hook, error) {
+	out := &hook{Hook: h, events: make(map[Event]bool), actions: make(map[state.Action]bool), timeout: time.Minute}
+	if h.Name == "" {
+		return nil, fmt.Errorf("hook without name")
+	}
+	if (len(h.Exec) == 0) == (h.Webhook == "") {
+		return nil, fmt.Errorf("hook %s: either exec or webhook must be given", h.Name)
+	}
+	if len(h.Events) == 0 {
+		return nil, fmt.Errorf("hook %s: no events given (valid events: %v)", h.Name, events)
+	}
+	for _, e := range h.Events {
+		if !includesEvent(events, Event(e)) {
+			return nil, fmt.Errorf("hook %s: invalid event %s (valid events: %v)", h.Name, e, events)
+		}
+		out.events[Event(e)] = true
+	}
+	for _, a := range h.Actions {
+		action, err := state.ActionString(a)
+		if err != nil {
+			return nil, fmt.Errorf("hook %s: invalid action %s (valid actions: %v)",
+				h.Name, a, state.ActionValues())
+		}
+		out.actions[action] = true
+	}
+	if h.Where != "" {
+		filter, err := query.Parse(h.Where)
+		if err != nil {
+			return nil, fmt.Errorf("hook %s: %s", h.Name, err)
+		}
+		out.filter = filter
+	}
+	if h.Timeout != "" {
+		timeout, err := time.ParseDuration(h.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("hook %s: invalid timeout %s", h.Name, h.Timeout)
+		}
+		out.timeout = timeout
+	}
+	return out, nil
+}
+
+// BeforeAction runs the before_action hooks for action on cred and returns
+// the error of the first hook vetoing it.
+func (r *Runner) BeforeAction(cred *state.Credential, action state.Action) error {
+	return r.run(Context{Event: BeforeAction, Action: action.String(), Credential: cred}, cred, action)
+}
+
+// AfterAction runs the after_action hooks for action on cred, actionErr is
+// the error the action failed with.
+func (r *Runner) AfterAction(cred *state.Credential, action state.Action, actionErr error) error {
+	ctx := Context{Event: AfterAction, Action: action.String(), Credential: cred}
+	if actionErr != nil {
+		ctx.Error = actionErr.Error()
+	}
+	return r.run(ctx, cred, action)
+}
+
+// BeforeDeployRound runs the before_deploy_round hooks and returns the error
+// of the first hook vetoing the round.
+func (r *Runner) BeforeDeployRound(round int, actions []RoundAction, deployments []string) error {
+	return r.run(Context{Event: BeforeDeployRound, Round: round, Actions: actions, Deployments: deployments},
+		nil, state.None)
+}
+
+// AfterDeployRound runs the after_deploy_round hooks.
+func (r *Runner) AfterDeployRound(round int, actions []RoundAction, deployments []string) error {
+	return r.run(Context{Event: AfterDeployRound, Round: round, Actions: actions, Deployments: deployments},
+		nil, state.None)
+}
+
+// run runs the hooks matching ctx, stopping at the first failure of a before
+// event, and returns the failures.
+func (r *Runner) run(ctx Context, cred *state.Credential, action state.Action) error {
+	if r == nil {
+		return nil
+	}
+	ctx.Command = r.Command
+
+	failures := make([]string, 0)
+	for _, h := range r.hooks {
+		if !h.matches(ctx.Event, cred, action) {
+			continue
+		}
+		ctx.Hook = h.Name
+		if err := r.runHook(h, ctx); err != nil {
+			if ctx.Event.before() {
+				return fmt.Errorf("vetoed by hook %s: %s", h.Name, err)
+			}
+			failures = append(failures, fmt.Sprintf("hook %s: %s", h.Name, err))
+		}
+	}
+	if len(failures) != 0 {
+		return fmt.Errorf("%s", strings.Join(failures, ", "))
+	}
+	return nil
+}
+
+func (h *hook) matches(event Event, cred *state.Credential, action state.Action) bool {
+	if !h.events[event] {
+		return false
+	}
+	if cred == nil {
+		return true
+	}
+	if len(h.actions) != 0 && !h.actions[action] {
+		return false
+	}
+	return h.filter == nil || h.filter(cred)
+}
+
+func (r *Runner) runHook(h *hook, ctx Context) error {
+	data, err := json.Marshal(ctx)
+	if err != nil {
+		return err
+	}
+
+	timeout, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	if len(h.Exec) != 0 {
+		cmd := exec.CommandContext(timeout, h.Exec[0], h.Exec[1:]...)
+		cmd.Stdin = bytes.NewReader(data)
+		cmd.Stdout, cmd.Stderr = r.Output, r.Output
+		return cmd.Run()
+	}
+
+	req, err := http.NewRequestWithContext(timeout, http.MethodPost, h.Webhook, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+func includesEvent(list []Event, e Event) bool {
+	for _, item := range list {
+		if item == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package hooks_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/credhub"
+	"github.com/cloudfoundry-community/carousel/hooks"
+	"github.com/cloudfoundry-community/carousel/state"
+)
+
+var _ = Describe("Hooks", func() {
+	var (
+		dir  string
+		cred *state.Credential
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "hooks")
+		Expect(err).ToNot(HaveOccurred())
+
+		cred = &state.Credential{
+			Credential: &credhub.Credential{
+				ID:                   "1",
+				Name:                 "/bosh/cf/ca",
+				Type:                 credhub.Certificate,
+				CertificateAuthority: true,
+				RawValue:             json.RawMessage(`{"private_key":"secret"}`),
+			},
+			Latest: true,
+			Path:   &state.Path{Name: "/bosh/cf/ca"},
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	// recorder returns an exec hook writing its context to a file in dir
+	// and exiting with status
+	recorder := func(name string, status string, events ...string) (hooks.Hook, string) {
+		out := filepath.Join(dir, name+".json")
+		return hooks.Hook{
+			Name:   name,
+			Events: events,
+			Exec:   []string{"sh", "-c", "cat > " + out + "; exit " + status},
+		}, out
+	}
+
+	readContext := func(path string) map[string]interface{} {
+		data, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		var out map[string]interface{}
+		Expect(json.Unmarshal(data, &out)).To(Succeed())
+		return out
+	}
+
+	Describe("NewRunner", func() {
+		It("rejects invalid hooks", func() {
+			for _, h := range []hooks.Hook{
+				{Events: []string{"after_action"}, Exec: []string{"true"}},
+				{Name: "none", Events: []string{"after_action"}},
+				{Name: "both", Events: []string{"after_action"}, Exec: []string{"true"}, Webhook: "http://localhost"},
+				{Name: "no-events", Exec: []string{"true"}},
+				{Name: "event", Events: []string{"after_everything"}, Exec: []string{"true"}},
+				{Name: "action", Events: []string{"after_action"}, Actions: []string{"Explode"}, Exec: []string{"true"}},
+				{Name: "where", Events: []string{"after_action"}, Where: "name ~", Exec: []string{"true"}},
+				{Name: "timeout", Events: []string{"after_action"}, Timeout: "soon", Exec: []string{"true"}},
+			} {
+				_, err := hooks.NewRunner([]hooks.Hook{h})
+				Expect(err).To(HaveOccurred(), h.Name)
+			}
+		})
+	})
+
+	Describe("BeforeAction", func() {
+		It("passes the credential context without its value", func() {
+			hook, out := recorder("ticket", "0", "before_action")
+			runner, err := hooks.NewRunner([]hooks.Hook{hook})
+			Expect(err).ToNot(HaveOccurred())
+			runner.Command = "carousel rotate"
+
+			Expect(runner.BeforeAction(cred, state.MarkTransitional)).To(Succeed())
+
+			ctx := readContext(out)
+			Expect(ctx["event"]).To(Equal("before_action"))
+			Expect(ctx["hook"]).To(Equal("ticket"))
+			Expect(ctx["command"]).To(Equal("carousel rotate"))
+			Expect(ctx["action"]).To(Equal("MarkTransitional"))
+			Expect(ctx["credential"]).To(HaveKeyWithValue("name", "/bosh/cf/ca"))
+			Expect(ctx["credential"]).ToNot(HaveKey("value"))
+		})
+
+		It("vetoes the action when a hook fails", func() {
+			veto, _ := recorder("veto", "1", "before_action")
+			after, out := recorder("after", "0", "before_action")
+			runner, err := hooks.NewRunner([]hooks.Hook{veto, after})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = runner.BeforeAction(cred, state.CleanUp)
+			Expect(err).To(MatchError(ContainSubstring("vetoed by hook veto")))
+			Expect(out).ToNot(BeAnExistingFile())
+		})
+
+		It("only runs hooks selecting the action and credential", func() {
+			byAction, actionOut := recorder("by-action", "0", "before_action")
+			byAction.Actions = []string{"Regenerate"}
+			byWhere, whereOut := recorder("by-where", "0", "before_action")
+			byWhere.Where = "name ~ '^/concourse/'"
+			runner, err := hooks.NewRunner([]hooks.Hook{byAction, byWhere})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.BeforeAction(cred, state.CleanUp)).To(Succeed())
+			Expect(actionOut).ToNot(BeAnExistingFile())
+			Expect(whereOut).ToNot(BeAnExistingFile())
+
+			Expect(runner.BeforeAction(cred, state.Regenerate)).To(Succeed())
+			Expect(actionOut).To(BeAnExistingFile())
+		})
+	})
+
+	Describe("AfterAction", func() {
+		It("passes the error and reports failures without stopping", func() {
+			failing, _ := recorder("failing", "1", "after_action")
+			next, out := recorder("next", "0", "after_action")
+			runner, err := hooks.NewRunner([]hooks.Hook{failing, next})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = runner.AfterAction(cred, state.Regenerate, os.ErrPermission)
+			Expect(err).To(MatchError(ContainSubstring("hook failing")))
+			Expect(readContext(out)["error"]).To(Equal(os.ErrPermission.Error()))
+		})
+	})
+
+	Describe("webhooks", func() {
+		var (
+			server   *httptest.Server
+			status   int
+			received map[string]interface{}
+		)
+
+		BeforeEach(func() {
+			status, received = http.StatusOK, nil
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.Method).To(Equal(http.MethodPost))
+				Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+				Expect(json.NewDecoder(r.Body).Decode(&received)).To(Succeed())
+				w.WriteHeader(status)
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("posts the deploy round context", func() {
+			runner, err := hooks.NewRunner([]hooks.Hook{{Name: "chat", Events: []string{"after_deploy_round"}, Webhook: server.URL}})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.BeforeDeployRound(1, nil, nil)).To(Succeed())
+			Expect(received).To(BeNil())
+
+			Expect(runner.AfterDeployRound(2, []hooks.RoundAction{{Action: "Regenerate", Name: "/bosh/cf/ca", ID: "1"}},
+				[]string{"cf"})).To(Succeed())
+			Expect(received).To(HaveKeyWithValue("event", "after_deploy_round"))
+			Expect(received).To(HaveKeyWithValue("round", BeNumerically("==", 2)))
+			Expect(received).To(HaveKeyWithValue("deployments", ConsistOf("cf")))
+		})
+
+		It("vetoes the deploy round on a non 2xx response", func() {
+			status = http.StatusConflict
+			runner, err := hooks.NewRunner([]hooks.Hook{{Name: "gate", Events: []string{"before_deploy_round"}, Webhook: server.URL}})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = runner.BeforeDeployRound(1, nil, []string{"cf"})
+			Expect(err).To(MatchError(ContainSubstring("409")))
+		})
+	})
+
+	It("treats a nil runner as without hooks", func() {
+		var runner *hooks.Runner
+		Expect(runner.BeforeAction(cred, state.Regenerate)).To(Succeed())
+	})
+})
@@ -0,0 +1,224 @@
+// Package policy loads the optional policy file which tunes how carousel
+// decides what to do, for example:
+//
+//	rules:
+//	  disable: [age]
+//	  enable: [deploy-before-regenerate]
+//	  custom:
+//	  - name: leave-concourse-alone
+//	    where: name ~ '^/concourse/'
+//	    action: None
+//	    before: unmanaged-type
+//	dual_credentials:
+//	  where: name glob '/bosh/cf/*_password'
+//	  suffix: _previous
+//	windows:
+//	- name: weeknights
+//	  cron: 0 22 * * 1-5
+//	  duration: 4h
+//	  deployments: [cf*]
+//	freezes:
+//	- name: end-of-year
+//	  from: 2024-12-20
+//	  to: 2025-01-06
+//	rollout:
+//	  max_actions: 20
+//	  waves:
+//	  - name: canary
+//	    deployments: [cf-canary]
+//	hooks:
+//	- name: smoke-test
+//	  events: [after_action]
+//	  actions: [Regenerate]
+//	  exec: [/usr/local/bin/smoke-test]
+package policy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/cloudfoundry-community/carousel/hooks"
+	"github.com/cloudfoundry-community/carousel/query"
+	"github.com/cloudfoundry-community/carousel/state"
+)
+
+type Policy struct {
+	Rules           RulesConfig      `yaml:"rules"`
+	DualCredentials *DualCredentials `yaml:"dual_credentials"`
+	// Windows are the periods in which credentials may be changed
+	Windows []Window `yaml:"windows"`
+	// Freezes are the periods in which credentials must not be changed
+	Freezes []Window `yaml:"freezes"`
+	Rollout Rollout  `yaml:"rollout"`
+	// Hooks run before and after actions and deploy rounds
+	Hooks []hooks.Hook `yaml:"hooks"`
+}
+
+// DualCredentials enables the zero downtime rotation of the user and
+// password credentials matching Where, keeping their previous value at the
+// path of the credential followed by Suffix.
+type DualCredentials struct {
+	Where  string `yaml:"where"`
+	Suffix string `yaml:"suffix"`
+}
+
+// CompanionSuffix returns the configured suffix, or the default _previous.
+func (d *DualCredentials) CompanionSuffix() string {
+	if d == nil || d.Suffix == "" {
+		return "_previous"
+	}
+	return d.Suffix
+}
+
+type RulesConfig struct {
+	// Enable turns on optional built-in rules
+	Enable []string `yaml:"enable"`
+	// Disable turns off built-in rules
+	Disable []string     `yaml:"disable"`
+	Custom  []CustomRule `yaml:"custom"`
+}
+
+// CustomRule decides Action for the credentials matching the where
+// expression. Custom rules are evaluated before the built-in rules, unless
+// Before names the rule they should be evaluated in front of.
+type CustomRule struct {
+	Name   string `yaml:"name"`
+	Where  string `yaml:"where"`
+	Action string `yaml:"action"`
+	Before string `yaml:"before,omitempty"`
+}
+
+// Load reads the policy file at path, an empty path results in the default
+// policy.
+func Load(path string) (*Policy, error) {
+	var p Policy
+	if path == "" {
+		return &p, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %s got: %s", path, err)
+	}
+	if err := yaml.UnmarshalStrict(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %s got: %s", path, err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy: %s got: %s", path, err)
+	}
+	return &p, nil
+}
+
+// Parse parses a policy given inline, e.g. in the source of the concourse
+// resource.
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.UnmarshalStrict(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy got: %s", err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy got: %s", err)
+	}
+	return &p, nil
+}
+
+func (p *Policy) validate() error {
+	if _, err := p.RuleSet(time.Now()); err != nil {
+		return err
+	}
+	if _, err := p.Schedule(); err != nil {
+		return err
+	}
+	if err := p.Rollout.validate(); err != nil {
+		return err
+	}
+	_, err := p.HookRunner()
+	return err
+}
+
+// HookRunner returns a runner for the hooks of the policy.
+func (p *Policy) HookRunner() (*hooks.Runner, error) {
+	return hooks.NewRunner(p.Hooks)
+}
+
+// Schedule returns the maintenance windows and freezes of the policy.
+func (p *Policy) Schedule() (Schedule, error) {
+	var s Schedule
+	for _, w := range p.Windows {
+		w := w
+		if err := w.compile(); err != nil {
+			return Schedule{}, err
+		}
+		s.Windows = append(s.Windows, &w)
+	}
+	for _, w := range p.Freezes {
+		w := w
+		if err := w.compile(); err != nil {
+			return Schedule{}, fmt.Errorf("freeze: %s", err)
+		}
+		s.Freezes = append(s.Freezes, &w)
+	}
+	return s, nil
+}
+
+// RuleSet returns the built-in rules enabled by the policy with its custom
+// rules inserted in the order of the policy file. Durations in where
+// expressions are relative to now.
+func (p *Policy) RuleSet(now time.Time) (state.Rules, error) {
+	rules, err := state.RuleSet(p.Rules.Enable, p.Rules.Disable)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.DualCredentials != nil {
+		filter, err := query.ParseAt(p.DualCredentials.Where, now)
+		if err != nil {
+			return nil, fmt.Errorf("dual_credentials: %s", err)
+		}
+		rules = rules.InsertAfter("update-mode",
+			state.DualCredentialRule(p.DualCredentials.CompanionSuffix(), filter))
+	}
+
+	// custom rules without before go in front of all rules
+	front := make(state.Rules, 0)
+	for _, custom := range p.Rules.Custom {
+		rule, err := custom.rule(now)
+		if err != nil {
+			return nil, err
+		}
+		if custom.Before == "" {
+			front = append(front, rule)
+			continue
+		}
+		if rules, err = rules.Insert(custom.Before, rule); err != nil {
+			return nil, fmt.Errorf("custom rule %s: %s", custom.Name, err)
+		}
+	}
+	return append(front, rules...), nil
+}
+
+func (c CustomRule) rule(now time.Time) (state.Rule, error) {
+	if c.Name == "" {
+		return nil, fmt.Errorf("custom rule without name")
+	}
+
+	filter, err := query.ParseAt(c.Where, now)
+	if err != nil {
+		return nil, fmt.Errorf("custom rule %s: %s", c.Name, err)
+	}
+
+	action, err := state.ActionString(c.Action)
+	if err != nil {
+		return nil, fmt.Errorf("custom rule %s: invalid action %s (valid actions: %v)",
+			c.Name, c.Action, state.ActionValues())
+	}
+
+	return state.NewExplainedRule(c.Name, func(cred *state.Credential, r state.RegenerationCriteria) (state.Action, bool) {
+		return action, filter(cred)
+	}, func(cred *state.Credential, r state.RegenerationCriteria) []state.Value {
+		return []state.Value{{Name: "where", Value: c.Where}}
+	}), nil
+}
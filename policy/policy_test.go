@@ -0,0 +1,142 @@
+package policy_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/credhub"
+	. "github.com/cloudfoundry-community/carousel/policy"
+	"github.com/cloudfoundry-community/carousel/state"
+)
+
+var _ = Describe("Policy", func() {
+	var dir string
+
+	write := func(content string) string {
+		file := filepath.Join(dir, "policy.yml")
+		Expect(ioutil.WriteFile(file, []byte(content), 0600)).To(Succeed())
+		return file
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "policy")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("uses the default rules without a policy file", func() {
+		p, err := Load("")
+		Expect(err).ToNot(HaveOccurred())
+		rules, err := p.RuleSet(time.Now())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rules.Names()).To(Equal(state.DefaultRules().Names()))
+	})
+
+	It("enables, disables and adds rules", func() {
+		p, err := Load(write(`
+rules:
+  enable: [deploy-before-regenerate]
+  disable: [age]
+  custom:
+  - name: leave-concourse-alone
+    where: name ~ '^/concourse/'
+    action: None
+  - name: no-cleanup-of-bosh
+    where: name glob '/bosh/*'
+    action: None
+    before: cleanup
+`))
+		Expect(err).ToNot(HaveOccurred())
+
+		rules, err := p.RuleSet(time.Now())
+		Expect(err).ToNot(HaveOccurred())
+		names := rules.Names()
+		Expect(names[0]).To(Equal("leave-concourse-alone"))
+		Expect(names).To(ContainElement("deploy-before-regenerate"))
+		Expect(names).ToNot(ContainElement("age"))
+		Expect(names[len(names)-2:]).To(Equal([]string{"no-cleanup-of-bosh", "cleanup"}))
+	})
+
+	It("keeps the order of custom rules inserted at the same position", func() {
+		p, err := Load(write(`
+rules:
+  custom:
+  - {name: first, where: ca, action: None}
+  - {name: second, where: ca, action: None}
+  - {name: third, where: ca, action: None, before: cleanup}
+  - {name: fourth, where: ca, action: None, before: cleanup}
+`))
+		Expect(err).ToNot(HaveOccurred())
+
+		rules, err := p.RuleSet(time.Now())
+		Expect(err).ToNot(HaveOccurred())
+		names := rules.Names()
+		Expect(names[:2]).To(Equal([]string{"first", "second"}))
+		Expect(names[len(names)-3:]).To(Equal([]string{"third", "fourth", "cleanup"}))
+	})
+
+	It("evaluates durations of custom rules relative to the given time", func() {
+		p, err := Load(write(`
+rules:
+  custom:
+  - {name: old-passwords, where: type = password and age > 30d, action: Regenerate}
+`))
+		Expect(err).ToNot(HaveOccurred())
+
+		createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		cred := &state.Credential{Credential: &credhub.Credential{
+			Name: "/password", Type: credhub.Password, VersionCreatedAt: &createdAt,
+		}}
+		for now, matches := range map[time.Time]bool{
+			createdAt.AddDate(0, 0, 10): false,
+			createdAt.AddDate(0, 0, 40): true,
+		} {
+			rules, err := p.RuleSet(now)
+			Expect(err).ToNot(HaveOccurred())
+			_, applies := rules[0].Evaluate(cred, state.RegenerationCriteria{})
+			Expect(applies).To(Equal(matches), now.String())
+		}
+	})
+
+	It("adds the dual credential rule after update-mode", func() {
+		p, err := Load(write(`
+dual_credentials:
+  where: name glob '/bosh/*_password'
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p.DualCredentials.CompanionSuffix()).To(Equal("_previous"))
+
+		rules, err := p.RuleSet(time.Now())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rules.Names()[:3]).To(Equal([]string{"unmanaged-type", "update-mode", "dual-credential"}))
+	})
+
+	DescribeTable("rejecting invalid policies",
+		func(content, msg string) {
+			_, err := Load(write(content))
+			Expect(err).To(MatchError(ContainSubstring(msg)))
+		},
+		Entry("unknown keys", "rulez: {}", "failed to parse policy"),
+		Entry("unknown rules", "rules: {disable: [colour]}", "unknown rule: colour"),
+		Entry("invalid expressions", `rules: {custom: [{name: x, where: "colour = red", action: None}]}`,
+			"unknown field 'colour'"),
+		Entry("invalid actions", `rules: {custom: [{name: x, where: ca, action: Explode}]}`,
+			"invalid action Explode"),
+		Entry("unknown positions", `rules: {custom: [{name: x, where: ca, action: None, before: colour}]}`,
+			"rule not found: colour"),
+		Entry("invalid dual credential expressions", `dual_credentials: {where: "colour = red"}`,
+			"dual_credentials: "),
+		Entry("invalid hook events", `hooks: [{name: x, events: [after_everything], exec: ["true"]}]`,
+			"hook x: invalid event after_everything"),
+	)
+})
@@ -0,0 +1,174 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry-community/carousel/state"
+)
+
+// Rollout limits how much a single rotate run changes, so not every
+// deployment needs a redeploy at the same moment. Waves are groups of
+// deployments rotated in order, credentials used by a later wave are not
+// changed while the deployments of an earlier wave are pending a deploy or
+// are about to, as actions using them were admitted in the same round.
+// Deployments not matching any wave form an implicit last wave.
+type Rollout struct {
+	MaxActions     int    `yaml:"max_actions"`
+	MaxDeployments int    `yaml:"max_deployments"`
+	Waves          []Wave `yaml:"waves"`
+}
+
+type Wave struct {
+	Name        string   `yaml:"name"`
+	Deployments []string `yaml:"deployments"`
+}
+
+func (r Rollout) validate() error {
+	if r.MaxActions < 0 || r.MaxDeployments < 0 {
+		return fmt.Errorf("rollout: limits must not be negative")
+	}
+	for _, w := range r.Waves {
+		if w.Name == "" {
+			return fmt.Errorf("rollout: wave without name")
+		}
+		if len(w.Deployments) == 0 {
+			return fmt.Errorf("rollout: wave %s without deployments", w.Name)
+		}
+		if err := state.ValidateGlobs(w.Deployments...); err != nil {
+			return fmt.Errorf("rollout: wave %s: %s", w.Name, err)
+		}
+	}
+	return nil
+}
+
+// wave returns the index of the first wave matching the deployment name.
+func (r Rollout) wave(name string) int {
+	for i, w := range r.Waves {
+		if state.MatchesGlob(name, w.Deployments...) {
+			return i
+		}
+	}
+	return len(r.Waves)
+}
+
+func (r Rollout) waveName(i int) string {
+	if i == len(r.Waves) {
+		return "remaining deployments"
+	}
+	return r.Waves[i].Name
+}
+
+// Run returns a Limiter tracking the actions of a single rotate run.
+func (r Rollout) Run() *Limiter {
+	return &Limiter{
+		rollout:     r,
+		deployments: make(map[string]bool),
+		admitted:    make(map[string]bool),
+	}
+}
+
+type Limiter struct {
+	rollout     Rollout
+	actions     int
+	deployments map[string]bool
+	admitted    map[string]bool
+}
+
+// NextRound starts the next round of the run. The deploys needed by the
+// actions admitted so far are pending from now on, and are passed to Admit
+// as long as they are.
+func (l *Limiter) NextRound() {
+	l.admitted = make(map[string]bool)
+}
+
+// Admit decides whether an action on cred can be performed, pending are the
+// credentials currently waiting for a bosh deploy. An admitted action counts
+// towards the limits, otherwise the reason it is held back is returned.
+// Within a round the deployments of admitted actions hold back later waves
+// the same way pending deploys do.
+func (l *Limiter) Admit(cred *state.Credential, pending state.Credentials) (bool, string) {
+	if l.rollout.MaxActions != 0 && l.actions >= l.rollout.MaxActions {
+		return false, fmt.Sprintf("reached the maximum of %d action(s)", l.rollout.MaxActions)
+	}
+
+	if len(l.rollout.Waves) != 0 {
+		if wave, blocking := l.blockingWave(cred, pending); wave != -1 {
+			return false, fmt.Sprintf("waiting for wave %s to be deployed (%s)",
+				l.rollout.waveName(wave), strings.Join(blocking, ", "))
+		}
+	}
+
+	added := make([]string, 0)
+	for _, d := range cred.Path.Deployments {
+		if !l.deployments[d.Name] {
+			added = append(added, d.Name)
+		}
+	}
+	if l.rollout.MaxDeployments != 0 && len(l.deployments)+len(added) > l.rollout.MaxDeployments {
+		return false, fmt.Sprintf("would exceed the maximum of %d deployment(s)", l.rollout.MaxDeployments)
+	}
+
+	l.actions++
+	for _, name := range added {
+		l.deployments[name] = true
+	}
+	for _, d := range cred.Path.Deployments {
+		l.admitted[d.Name] = true
+	}
+	return true, ""
+}
+
+// blockingWave returns the first wave before the wave of cred with
+// deployments pending a deploy, or admitted in this round, and those
+// deployments, or -1.
+func (l *Limiter) blockingWave(cred *state.Credential, pending state.Credentials) (int, []string) {
+	if len(cred.Path.Deployments) == 0 {
+		return -1, nil
+	}
+	wave := len(l.rollout.Waves)
+	for _, d := range cred.Path.Deployments {
+		if w := l.rollout.wave(d.Name); w < wave {
+			wave = w
+		}
+	}
+
+	admitted := make([]string, 0, len(l.admitted))
+	for name := range l.admitted {
+		admitted = append(admitted, name)
+	}
+	sort.Strings(admitted)
+
+	names := make([]string, 0)
+	for _, p := range pending {
+		for _, d := range p.PendingDeploys() {
+			names = append(names, d.Name)
+		}
+	}
+	names = append(names, admitted...)
+
+	first, blocking := -1, make([]string, 0)
+	for _, name := range names {
+		w := l.rollout.wave(name)
+		if w >= wave || (first != -1 && w > first) {
+			continue
+		}
+		if w != first {
+			first, blocking = w, blocking[:0]
+		}
+		if !includesString(blocking, name) {
+			blocking = append(blocking, name)
+		}
+	}
+	return first, blocking
+}
+
+func includesString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package policy_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/bosh"
+	"github.com/cloudfoundry-community/carousel/credhub"
+	. "github.com/cloudfoundry-community/carousel/policy"
+	"github.com/cloudfoundry-community/carousel/state"
+)
+
+var _ = Describe("Rollout", func() {
+	var (
+		s       state.State
+		rollout Rollout
+	)
+
+	latest := func(name string) *state.Credential {
+		cred, found := s.Credentials(state.NameFilter(name), state.LatestFilter()).Find(state.LatestFilter())
+		Expect(found).To(BeTrue())
+		return cred
+	}
+
+	BeforeEach(func() {
+		now := time.Now()
+		password := func(name, id string) *credhub.Credential {
+			createdAt := now
+			now = now.Add(time.Minute)
+			return &credhub.Credential{ID: id, Name: name, Type: credhub.Password, VersionCreatedAt: &createdAt}
+		}
+		s = state.NewState()
+		Expect(s.Update([]*credhub.Credential{
+			password("/canary_pw", "canary-1"),
+			password("/canary_pw", "canary-2"),
+			password("/cf_pw", "cf"),
+			password("/shared_pw", "shared"),
+			password("/unused_pw", "unused"),
+		}, []*bosh.Variable{
+			{ID: "canary-1", Name: "/canary_pw", Deployment: "cf-canary"},
+			{ID: "cf", Name: "/cf_pw", Deployment: "cf-1"},
+			{ID: "shared", Name: "/shared_pw", Deployment: "cf-canary"},
+			{ID: "shared", Name: "/shared_pw", Deployment: "cf-1"},
+		})).To(Succeed())
+
+		rollout = Rollout{Waves: []Wave{
+			{Name: "canary", Deployments: []string{"cf-canary"}},
+			{Name: "main", Deployments: []string{"cf-*"}},
+		}}
+	})
+
+	Context("waves", func() {
+		var pending state.Credentials
+
+		BeforeEach(func() {
+			pending = state.Credentials{latest("/canary_pw")}
+		})
+
+		It("holds back later waves while an earlier wave is pending a deploy", func() {
+			admitted, reason := rollout.Run().Admit(latest("/cf_pw"), pending)
+			Expect(admitted).To(BeFalse())
+			Expect(reason).To(Equal("waiting for wave canary to be deployed (cf-canary)"))
+		})
+
+		It("admits credentials used by the pending wave", func() {
+			admitted, _ := rollout.Run().Admit(latest("/shared_pw"), pending)
+			Expect(admitted).To(BeTrue())
+		})
+
+		It("admits credentials not used by any deployment", func() {
+			admitted, _ := rollout.Run().Admit(latest("/unused_pw"), pending)
+			Expect(admitted).To(BeTrue())
+		})
+
+		It("holds back later waves while an earlier wave was admitted in the same round", func() {
+			run := rollout.Run()
+			admitted, _ := run.Admit(latest("/canary_pw"), nil)
+			Expect(admitted).To(BeTrue())
+			admitted, reason := run.Admit(latest("/cf_pw"), nil)
+			Expect(admitted).To(BeFalse())
+			Expect(reason).To(Equal("waiting for wave canary to be deployed (cf-canary)"))
+
+			run.NextRound()
+			admitted, _ = run.Admit(latest("/cf_pw"), nil)
+			Expect(admitted).To(BeTrue())
+		})
+
+		It("admits later waves once the earlier waves are deployed", func() {
+			admitted, _ := rollout.Run().Admit(latest("/cf_pw"), nil)
+			Expect(admitted).To(BeTrue())
+		})
+	})
+
+	It("limits the number of actions", func() {
+		run := Rollout{MaxActions: 1}.Run()
+		admitted, _ := run.Admit(latest("/cf_pw"), nil)
+		Expect(admitted).To(BeTrue())
+		admitted, reason := run.Admit(latest("/shared_pw"), nil)
+		Expect(admitted).To(BeFalse())
+		Expect(reason).To(Equal("reached the maximum of 1 action(s)"))
+	})
+
+	It("limits the number of deployments", func() {
+		run := Rollout{MaxDeployments: 1}.Run()
+		admitted, _ := run.Admit(latest("/cf_pw"), nil)
+		Expect(admitted).To(BeTrue())
+		admitted, reason := run.Admit(latest("/shared_pw"), nil)
+		Expect(admitted).To(BeFalse())
+		Expect(reason).To(Equal("would exceed the maximum of 1 deployment(s)"))
+		admitted, _ = run.Admit(latest("/unused_pw"), nil)
+		Expect(admitted).To(BeTrue())
+	})
+
+	DescribeTable("rejecting invalid rollouts",
+		func(content, msg string) {
+			_, err := Parse([]byte(content))
+			Expect(err).To(MatchError(ContainSubstring(msg)))
+		},
+		Entry("negative limits", `{rollout: {max_actions: -1}}`, "limits must not be negative"),
+		Entry("waves without name", `{rollout: {waves: [{deployments: [cf]}]}}`, "wave without name"),
+		Entry("waves without deployments", `{rollout: {waves: [{name: canary}]}}`, "wave canary without deployments"),
+	)
+})
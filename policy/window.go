@@ -0,0 +1,208 @@
+package policy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/karrick/tparse"
+
+	"github.com/cloudfoundry-community/carousel/state"
+)
+
+// Window is a period of time, either opening according to Cron and staying
+// open for Duration, or the calendar range From (inclusive) To (exclusive).
+// A window only applies to the paths and deployments matching its globs,
+// without globs it applies to all of them.
+type Window struct {
+	Name        string   `yaml:"name"`
+	Cron        string   `yaml:"cron,omitempty"`
+	Duration    string   `yaml:"duration,omitempty"`
+	From        string   `yaml:"from,omitempty"`
+	To          string   `yaml:"to,omitempty"`
+	Timezone    string   `yaml:"timezone,omitempty"`
+	Paths       []string `yaml:"paths,omitempty"`
+	Deployments []string `yaml:"deployments,omitempty"`
+
+	cron     *cron
+	duration time.Duration
+	from, to time.Time
+	location *time.Location
+}
+
+var calendarLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"}
+
+func (w *Window) compile() error {
+	if w.Name == "" {
+		return fmt.Errorf("window without name")
+	}
+
+	location := time.Local
+	if w.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(w.Timezone); err != nil {
+			return fmt.Errorf("window %s: invalid timezone %s", w.Name, w.Timezone)
+		}
+	}
+
+	if err := state.ValidateGlobs(append(append([]string{}, w.Paths...), w.Deployments...)...); err != nil {
+		return fmt.Errorf("window %s: %s", w.Name, err)
+	}
+
+	switch {
+	case w.Cron != "" && w.From == "" && w.To == "":
+		c, err := parseCron(w.Cron)
+		if err != nil {
+			return fmt.Errorf("window %s: %s", w.Name, err)
+		}
+		ref := time.Now()
+		end, err := tparse.AddDuration(ref, "+"+w.Duration)
+		if err != nil || !end.After(ref) {
+			return fmt.Errorf("window %s: invalid duration %s (suffixes: m minute, h hour, d day, w week)",
+				w.Name, w.Duration)
+		}
+		w.cron, w.duration, w.location = c, end.Sub(ref), location
+	case w.Cron == "" && w.From != "" && w.To != "":
+		var err error
+		if w.from, err = parseCalendar(w.From, location, false); err != nil {
+			return fmt.Errorf("window %s: invalid from %s", w.Name, w.From)
+		}
+		if w.to, err = parseCalendar(w.To, location, true); err != nil {
+			return fmt.Errorf("window %s: invalid to %s", w.Name, w.To)
+		}
+		if !w.to.After(w.from) {
+			return fmt.Errorf("window %s: to must be after from", w.Name)
+		}
+	default:
+		return fmt.Errorf("window %s: either cron and duration or from and to must be given", w.Name)
+	}
+	return nil
+}
+
+// ParseTime parses a date or a time in the local timezone, e.g.
+// 2024-12-24, 2024-12-24T22:00 or 2024-12-24T22:00:00+01:00.
+func ParseTime(value string) (time.Time, error) {
+	return parseCalendar(value, time.Local, false)
+}
+
+// parseCalendar parses a date or a time, a date given as end of a range
+// includes the whole day.
+func parseCalendar(value string, location *time.Location, end bool) (time.Time, error) {
+	for _, layout := range calendarLayouts {
+		t, err := time.ParseInLocation(layout, value, location)
+		if err != nil {
+			continue
+		}
+		if end && layout == "2006-01-02" {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("expected a date or time got %s", value)
+}
+
+func (w *Window) applies(path string, deployments []string) bool {
+	if len(w.Paths) != 0 && !state.MatchesGlob(path, w.Paths...) {
+		return false
+	}
+	if len(w.Deployments) == 0 {
+		return true
+	}
+	for _, d := range deployments {
+		if state.MatchesGlob(d, w.Deployments...) {
+			return true
+		}
+	}
+	return false
+}
+
+// openAt reports whether w is open at t and when the occurrence open at t
+// closes. Cron windows are evaluated in the timezone of the window.
+func (w *Window) openAt(t time.Time) (bool, time.Time) {
+	if w.cron == nil {
+		return !t.Before(w.from) && t.Before(w.to), w.to
+	}
+	t = t.In(w.location)
+	start, found := w.cron.next(t.Add(-w.duration).Add(time.Nanosecond))
+	if !found || start.After(t) {
+		return false, time.Time{}
+	}
+	return true, start.Add(w.duration)
+}
+
+// nextOpen returns when w opens next after t.
+func (w *Window) nextOpen(t time.Time) (time.Time, bool) {
+	if w.cron == nil {
+		return w.from, w.from.After(t)
+	}
+	return w.cron.next(t.In(w.location).Add(time.Nanosecond))
+}
+
+// Schedule decides when carousel may change the credentials used by
+// deployments: within any window applying to them, when there is one, but
+// never during a freeze.
+type Schedule struct {
+	Windows []*Window
+	Freezes []*Window
+}
+
+// Allowed reports whether the credential at path used by deployments may be
+// changed at now.
+func (s Schedule) Allowed(path string, deployments []string, now time.Time) bool {
+	next, found := s.NextOpen(path, deployments, now)
+	return found && !next.After(now)
+}
+
+// NextOpen returns the first time at or after now at which the credential at
+// path used by deployments may be changed, which is not found when no window
+// opens outside of a freeze within five years.
+func (s Schedule) NextOpen(path string, deployments []string, now time.Time) (time.Time, bool) {
+	windows, freezes := applying(s.Windows, path, deployments), applying(s.Freezes, path, deployments)
+
+	t, limit := now, now.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if frozen, until := openAny(freezes, t); frozen {
+			t = until
+			continue
+		}
+		if len(windows) == 0 {
+			return t, true
+		}
+		if open, _ := openAny(windows, t); open {
+			return t, true
+		}
+
+		var next time.Time
+		for _, w := range windows {
+			if n, found := w.nextOpen(t); found && (next.IsZero() || n.Before(next)) {
+				next = n
+			}
+		}
+		if next.IsZero() {
+			break
+		}
+		t = next
+	}
+	return time.Time{}, false
+}
+
+func applying(windows []*Window, path string, deployments []string) []*Window {
+	out := make([]*Window, 0)
+	for _, w := range windows {
+		if w.applies(path, deployments) {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// openAny reports whether any of windows is open at t and until when at
+// least one of them stays open.
+func openAny(windows []*Window, t time.Time) (bool, time.Time) {
+	var until time.Time
+	for _, w := range windows {
+		if open, end := w.openAt(t); open && end.After(until) {
+			until = end
+		}
+	}
+	return !until.IsZero(), until
+}
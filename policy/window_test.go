@@ -0,0 +1,116 @@
+package policy_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry-community/carousel/policy"
+)
+
+var _ = Describe("Schedule", func() {
+	var schedule Schedule
+
+	at := func(value string) time.Time {
+		t, err := time.Parse("2006-01-02 15:04", value)
+		Expect(err).ToNot(HaveOccurred())
+		return t
+	}
+
+	parse := func(content string) Schedule {
+		p, err := Parse([]byte(content))
+		Expect(err).ToNot(HaveOccurred())
+		s, err := p.Schedule()
+		Expect(err).ToNot(HaveOccurred())
+		return s
+	}
+
+	Context("without windows", func() {
+		It("allows changes at any time", func() {
+			Expect(parse("{}").Allowed("/a", nil, at("2024-12-24 13:00"))).To(BeTrue())
+		})
+	})
+
+	Context("with windows and freezes", func() {
+		BeforeEach(func() {
+			// 2024-12-16 is a monday
+			schedule = parse(`
+windows:
+- name: weeknights
+  cron: 0 22 * * 1-5
+  duration: 4h
+  timezone: UTC
+  deployments: [cf*]
+- name: bosh
+  cron: 30 6 1,15 * *
+  duration: 1h
+  timezone: UTC
+  paths: [/bosh/*]
+freezes:
+- name: end-of-year
+  from: 2024-12-20
+  to: 2025-01-05
+  timezone: UTC
+`)
+		})
+
+		DescribeTable("next window",
+			func(path string, deployments []string, now, expected string) {
+				next, found := schedule.NextOpen(path, deployments, at(now))
+				Expect(found).To(BeTrue())
+				Expect(next).To(BeTemporally("==", at(expected)))
+				Expect(schedule.Allowed(path, deployments, at(now))).To(Equal(now == expected))
+			},
+			Entry("within a window", "/cf/a", []string{"cf"}, "2024-12-16 23:00", "2024-12-16 23:00"),
+			Entry("window past midnight", "/cf/a", []string{"cf"}, "2024-12-17 01:59", "2024-12-17 01:59"),
+			Entry("before a window", "/cf/a", []string{"cf"}, "2024-12-16 12:00", "2024-12-16 22:00"),
+			Entry("after a window", "/cf/a", []string{"cf"}, "2024-12-17 02:00", "2024-12-17 22:00"),
+			Entry("weekend", "/cf/a", []string{"cf"}, "2024-12-07 12:00", "2024-12-09 22:00"),
+			Entry("freeze", "/cf/a", []string{"cf"}, "2024-12-20 23:00", "2025-01-06 22:00"),
+			Entry("window of path", "/bosh/a", []string{"bosh"}, "2024-12-03 12:00", "2024-12-15 06:30"),
+			Entry("not matching a window", "/redis/a", []string{"redis"}, "2024-12-16 12:00", "2024-12-16 12:00"),
+			Entry("freeze without window", "/redis/a", []string{"redis"}, "2024-12-25 12:00", "2025-01-06 00:00"),
+		)
+	})
+
+	It("evaluates cron windows in their timezone", func() {
+		schedule = parse(`{windows: [{name: nightly, cron: 0 22 * * *, duration: 1h, timezone: Asia/Tokyo}]}`)
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		Expect(err).ToNot(HaveOccurred())
+
+		// 22:30 in Tokyo is 14:30 in Berlin during winter
+		now := time.Date(2024, 12, 16, 14, 30, 0, 0, berlin)
+		Expect(schedule.Allowed("/a", nil, now)).To(BeTrue())
+		Expect(schedule.Allowed("/a", nil, time.Date(2024, 12, 16, 22, 30, 0, 0, berlin))).To(BeFalse())
+
+		next, found := schedule.NextOpen("/a", nil, time.Date(2024, 12, 16, 16, 0, 0, 0, berlin))
+		Expect(found).To(BeTrue())
+		Expect(next).To(BeTemporally("==", time.Date(2024, 12, 17, 14, 0, 0, 0, berlin)))
+	})
+
+	It("never allows changes when no window opens", func() {
+		schedule = parse(`{windows: [{name: never, cron: 0 0 30 2 *, duration: 1h}]}`)
+		_, found := schedule.NextOpen("/a", nil, time.Now())
+		Expect(found).To(BeFalse())
+		Expect(schedule.Allowed("/a", nil, time.Now())).To(BeFalse())
+	})
+
+	DescribeTable("rejecting invalid windows",
+		func(content, msg string) {
+			_, err := Parse([]byte(content))
+			Expect(err).To(MatchError(ContainSubstring(msg)))
+		},
+		Entry("missing names", `{windows: [{cron: "* * * * *", duration: 1h}]}`, "window without name"),
+		Entry("missing durations", `{windows: [{name: x, cron: "* * * * *"}]}`, "invalid duration"),
+		Entry("short cron expressions", `{windows: [{name: x, cron: "* * *", duration: 1h}]}`, "must have 5 fields"),
+		Entry("out of range values", `{windows: [{name: x, cron: "0 24 * * *", duration: 1h}]}`, "invalid hour"),
+		Entry("cron and calendar ranges", `{windows: [{name: x, cron: "* * * * *", duration: 1h, from: 2024-01-01}]}`,
+			"either cron and duration or from and to"),
+		Entry("invalid dates", `{freezes: [{name: x, from: 2024-13-01, to: 2025-01-01}]}`, "invalid from"),
+		Entry("reversed ranges", `{freezes: [{name: x, from: 2025-01-01, to: 2024-01-01}]}`, "to must be after from"),
+		Entry("invalid timezones", `{freezes: [{name: x, from: 2025-01-01, to: 2025-01-02, timezone: Mars}]}`,
+			"invalid timezone"),
+	)
+})
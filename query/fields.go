@@ -0,0 +1,249 @@
+package query
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karrick/tparse"
+
+	"github.com/cloudfoundry-community/carousel/bosh"
+	"github.com/cloudfoundry-community/carousel/credhub"
+	"github.com/cloudfoundry-community/carousel/state"
+)
+
+type fieldKind int
+
+const (
+	stringField fieldKind = iota
+	boolField
+	durationField
+	numberField
+)
+
+func (k fieldKind) String() string {
+	switch k {
+	case stringField:
+		return "string"
+	case boolField:
+		return "boolean"
+	case durationField:
+		return "duration"
+	default:
+		return "number"
+	}
+}
+
+func (k fieldKind) supports(op string) bool {
+	switch op {
+	case "=", "!=":
+		return true
+	case "~", "!~", "glob":
+		return k == stringField
+	default:
+		return k == durationField || k == numberField
+	}
+}
+
+type field struct {
+	kind     fieldKind
+	strings  func(*state.Credential) []string
+	bool     func(*state.Credential) bool
+	duration func(c *state.Credential, now time.Time) (time.Duration, bool)
+	number   func(*state.Credential) int
+	values   []string // valid values of a string field, any when empty
+}
+
+var relations = map[string]state.Collector{
+	"signed_by":     state.SignedByCollector(),
+	"signs":         state.SignsCollector(),
+	"references":    state.ReferencesCollector(),
+	"referenced_by": state.ReferencedByCollector(),
+}
+
+var fields = map[string]field{
+	"name": {kind: stringField, strings: func(c *state.Credential) []string {
+		return []string{c.Name}
+	}},
+	"path": {kind: stringField, strings: func(c *state.Credential) []string {
+		return []string{c.Name}
+	}},
+	"id": {kind: stringField, strings: func(c *state.Credential) []string {
+		return []string{c.ID}
+	}},
+	"type": {kind: stringField, values: credhub.CredentialTypeStringValues(),
+		strings: func(c *state.Credential) []string {
+			return []string{c.Type.String()}
+		}},
+	"deployment": {kind: stringField, strings: func(c *state.Credential) []string {
+		return c.Path.Deployments.Names()
+	}},
+	"deployed_to": {kind: stringField, strings: func(c *state.Credential) []string {
+		return c.Deployments.Names()
+	}},
+	"issuer_status": {kind: stringField, strings: func(c *state.Credential) []string {
+		return []string{string(c.IssuerStatus)}
+	}},
+	// unknown for paths no deployment defines a variable for
+	"update_mode": {kind: stringField,
+		values: []string{string(bosh.NoOverwrite), string(bosh.Overwrite), string(bosh.Converge), "unknown"},
+		strings: func(c *state.Credential) []string {
+			if c.Path.VariableDefinition == nil {
+				return []string{"unknown"}
+			}
+			return []string{string(c.Path.VariableDefinition.UpdateMode)}
+		}},
+	"phase": {kind: stringField, values: phaseNames(), strings: func(c *state.Credential) []string {
+		return []string{c.Path.Phase().String()}
+	}},
+
+	"latest":       {kind: boolField, bool: state.LatestFilter()},
+	"transitional": {kind: boolField, bool: state.TransitionalFilter()},
+	"signing":      {kind: boolField, bool: state.SigningFilter()},
+	"ca":           {kind: boolField, bool: state.CertificateAuthorityFilter(true)},
+	"active":       {kind: boolField, bool: state.ActiveFilter()},
+	"flagged":      {kind: boolField, bool: state.FlaggedIssuerFilter()},
+	"self_signed": {kind: boolField, bool: func(c *state.Credential) bool {
+		return c.SelfSigned
+	}},
+	"generated": {kind: boolField, bool: func(c *state.Credential) bool {
+		return c.Generated
+	}},
+	"unused": {kind: boolField, bool: state.UnusedFilter()},
+	// e.g. json credentials bundling certificates
+	"contains_pem": {kind: boolField, bool: func(c *state.Credential) bool {
+		return bytes.Contains(c.RawValue, []byte("-----BEGIN CERTIFICATE-----"))
+	}},
+
+	"expires_in": {kind: durationField, duration: func(c *state.Credential, now time.Time) (time.Duration, bool) {
+		if c.ExpiryDate == nil {
+			return 0, false
+		}
+		return c.ExpiryDate.Sub(now), true
+	}},
+	"age": {kind: durationField, duration: func(c *state.Credential, now time.Time) (time.Duration, bool) {
+		if c.VersionCreatedAt == nil {
+			return 0, false
+		}
+		return now.Sub(*c.VersionCreatedAt), true
+	}},
+
+	"pending_deploys": {kind: numberField, number: func(c *state.Credential) int {
+		return len(c.PendingDeploys())
+	}},
+	"versions": {kind: numberField, number: func(c *state.Credential) int {
+		return len(c.Path.Versions)
+	}},
+}
+
+func (f field) compare(p *parser, op string, value token) (state.Filter, error) {
+	switch f.kind {
+	case stringField:
+		return f.compareString(op, value)
+	case boolField:
+		expected, err := strconv.ParseBool(value.value)
+		if err != nil {
+			return nil, &Error{value.pos, fmt.Sprintf("expected true or false got %s", value)}
+		}
+		if op == "!=" {
+			expected = !expected
+		}
+		return func(c *state.Credential) bool {
+			return f.bool(c) == expected
+		}, nil
+	case durationField:
+		t, err := tparse.AddDuration(p.now, "+"+value.value)
+		if err != nil {
+			return nil, &Error{value.pos, fmt.Sprintf(
+				"expected duration (suffixes: h hour, d day, w week, mo month, y year) got %s", value)}
+		}
+		expected, now := t.Sub(p.now), p.now
+		return func(c *state.Credential) bool {
+			d, found := f.duration(c, now)
+			return found && compareInts(int64(d), op, int64(expected))
+		}, nil
+	default:
+		expected, err := strconv.Atoi(value.value)
+		if err != nil {
+			return nil, &Error{value.pos, fmt.Sprintf("expected number got %s", value)}
+		}
+		return func(c *state.Credential) bool {
+			return compareInts(int64(f.number(c)), op, int64(expected))
+		}, nil
+	}
+}
+
+func (f field) compareString(op string, value token) (state.Filter, error) {
+	var match func(string) bool
+
+	switch op {
+	case "=", "!=":
+		if len(f.values) != 0 && !includes(f.values, value.value) {
+			return nil, &Error{value.pos, fmt.Sprintf("expected one of %s got %s",
+				strings.Join(f.values, ", "), value)}
+		}
+		match = func(s string) bool { return s == value.value }
+	case "~", "!~":
+		re, err := regexp.Compile(value.value)
+		if err != nil {
+			return nil, &Error{value.pos, fmt.Sprintf("invalid regex %s got: %s", value, err)}
+		}
+		match = re.MatchString
+	case "glob":
+		if err := state.ValidateGlobs(value.value); err != nil {
+			return nil, &Error{value.pos, err.Error()}
+		}
+		match = func(s string) bool { return state.MatchesGlob(s, value.value) }
+	}
+
+	filter := func(c *state.Credential) bool {
+		for _, s := range f.strings(c) {
+			if match(s) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if strings.HasPrefix(op, "!") {
+		return state.NotFilter(filter), nil
+	}
+	return filter, nil
+}
+
+func compareInts(a int64, op string, b int64) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	default:
+		return a >= b
+	}
+}
+
+func phaseNames() []string {
+	out := make([]string, 0)
+	for _, phase := range state.RotationPhaseValues() {
+		out = append(out, phase.String())
+	}
+	return out
+}
+
+func includes(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
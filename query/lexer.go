@@ -0,0 +1,136 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenAnd
+	tokenOr
+	tokenNot
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return fmt.Sprintf("%q", t.value)
+	default:
+		return fmt.Sprintf("'%s'", t.value)
+	}
+}
+
+// operators ordered so that longer operators are matched first
+var operators = []string{"<=", ">=", "!=", "!~", "&&", "||", "=", "<", ">", "~", "!"}
+
+// lex splits an expression into tokens. Words are anything up to the next
+// space, parenthesis, quote or operator, so paths, globs and durations like
+// /bosh/cf/*_ca or 60d do not need to be quoted.
+func lex(expr string) ([]token, error) {
+	out := make([]token, 0)
+	runes := []rune(expr)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			out = append(out, token{tokenLParen, "(", i})
+			i++
+		case r == ')':
+			out = append(out, token{tokenRParen, ")", i})
+			i++
+		case r == '"' || r == '\'':
+			start := i
+			var value strings.Builder
+			for i++; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				value.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, &Error{start, "unterminated string"}
+			}
+			out = append(out, token{tokenString, value.String(), start})
+			i++
+		default:
+			if op, found := operatorAt(runes, i); found {
+				out = append(out, operatorToken(op, i))
+				i += len(op)
+				continue
+			}
+			start := i
+			for i < len(runes) && !isDelimiter(runes, i) {
+				i++
+			}
+			out = append(out, wordToken(string(runes[start:i]), start))
+		}
+	}
+
+	return append(out, token{tokenEOF, "", len(runes)}), nil
+}
+
+func operatorAt(runes []rune, i int) (string, bool) {
+	for _, op := range operators {
+		if strings.HasPrefix(string(runes[i:]), op) {
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func operatorToken(op string, pos int) token {
+	switch op {
+	case "&&":
+		return token{tokenAnd, op, pos}
+	case "||":
+		return token{tokenOr, op, pos}
+	case "!":
+		return token{tokenNot, op, pos}
+	default:
+		return token{tokenOperator, op, pos}
+	}
+}
+
+func wordToken(word string, pos int) token {
+	switch strings.ToLower(word) {
+	case "and":
+		return token{tokenAnd, word, pos}
+	case "or":
+		return token{tokenOr, word, pos}
+	case "not":
+		return token{tokenNot, word, pos}
+	case "glob":
+		return token{tokenOperator, "glob", pos}
+	default:
+		return token{tokenWord, word, pos}
+	}
+}
+
+func isDelimiter(runes []rune, i int) bool {
+	r := runes[i]
+	if unicode.IsSpace(r) || r == '(' || r == ')' || r == '"' || r == '\'' {
+		return true
+	}
+	_, found := operatorAt(runes, i)
+	return found
+}
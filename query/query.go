@@ -0,0 +1,233 @@
+// Package query implements the expression language of the --where flag,
+// parsing expressions like
+//
+//	type = certificate and deployment glob cf-* and signed_by /bosh/cf/diego_ca
+//	  and expires_in < 60d and not transitional
+//
+// into a state.Filter.
+//
+// Comparisons are written as `field operator value` where the operator is one
+// of = != < <= > >= ~ (regex) !~ (not regex) or glob (see path.Match). Boolean
+// fields can be used on their own. Expressions are combined with and (&&),
+// or (||), not (!) and parentheses. Relations (signed_by, signs, references
+// and referenced_by) take either a path glob or a parenthesized expression
+// which at least one related credential must match, e.g.
+// signed_by(ca and expires_in < 30d).
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-community/carousel/state"
+)
+
+// Error is returned for expressions which can not be parsed, Pos is the
+// offset of the offending token in the expression.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid where expression at position %d: %s", e.Pos, e.Msg)
+}
+
+// Parse parses expr into a state.Filter, durations are relative to the
+// current time.
+func Parse(expr string) (state.Filter, error) {
+	return ParseAt(expr, time.Now())
+}
+
+// ParseAt parses expr into a state.Filter with durations relative to now.
+func ParseAt(expr string, now time.Time) (state.Filter, error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens, now: now}
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, &Error{t.pos, fmt.Sprintf("unexpected %s", t)}
+	}
+	return filter, nil
+}
+
+// HasComparison reports whether expr contains a comparison operator, e.g.
+// to tell a where expression from a path searched for.
+func HasComparison(expr string) bool {
+	tokens, err := lex(expr)
+	if err != nil {
+		return false
+	}
+	for _, t := range tokens {
+		if t.kind == tokenOperator {
+			return true
+		}
+	}
+	return false
+}
+
+// Fields returns the names of all fields and relations which can be used in
+// an expression.
+func Fields() []string {
+	out := make([]string, 0, len(fields)+len(relations))
+	for name := range fields {
+		out = append(out, name)
+	}
+	for name := range relations {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	now    time.Time
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (state.Filter, error) {
+	filters := make([]state.Filter, 0)
+	for {
+		filter, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+		if p.peek().kind != tokenOr {
+			break
+		}
+		p.next()
+	}
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+	return state.OrFilter(filters...), nil
+}
+
+func (p *parser) parseAnd() (state.Filter, error) {
+	filters := make([]state.Filter, 0)
+	for {
+		filter, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+		if p.peek().kind != tokenAnd {
+			break
+		}
+		p.next()
+	}
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+	return state.AndFilter(filters...), nil
+}
+
+func (p *parser) parseNot() (state.Filter, error) {
+	if p.peek().kind == tokenNot {
+		p.next()
+		filter, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return state.NotFilter(filter), nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (state.Filter, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLParen:
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &Error{closing.pos, fmt.Sprintf("expected ')' got %s", closing)}
+		}
+		return filter, nil
+	case tokenWord:
+		name := strings.ToLower(t.value)
+		if collector, found := relations[name]; found {
+			return p.parseRelation(collector)
+		}
+		if f, found := fields[name]; found {
+			return p.parseComparison(t, f)
+		}
+		return nil, &Error{t.pos, fmt.Sprintf("unknown field '%s' (known fields: %s)",
+			t.value, strings.Join(Fields(), ", "))}
+	default:
+		return nil, &Error{t.pos, fmt.Sprintf("expected field or '(' got %s", t)}
+	}
+}
+
+// parseRelation parses the argument of a relation: either a parenthesized
+// expression or a path glob.
+func (p *parser) parseRelation(collector state.Collector) (state.Filter, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenLParen:
+		p.next()
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &Error{closing.pos, fmt.Sprintf("expected ')' got %s", closing)}
+		}
+		return state.RelatedFilter(collector, filter), nil
+	case tokenWord, tokenString:
+		p.next()
+		if err := state.ValidateGlobs(t.value); err != nil {
+			return nil, &Error{t.pos, err.Error()}
+		}
+		return state.RelatedFilter(collector, state.PathGlobFilter(t.value)), nil
+	default:
+		return nil, &Error{t.pos, fmt.Sprintf("expected path or '(' got %s", t)}
+	}
+}
+
+func (p *parser) parseComparison(name token, f field) (state.Filter, error) {
+	op := p.peek()
+	if op.kind != tokenOperator {
+		if f.kind == boolField {
+			return f.compare(p, "=", token{tokenWord, "true", name.pos})
+		}
+		return nil, &Error{op.pos, fmt.Sprintf("expected operator after '%s' got %s", name.value, op)}
+	}
+	p.next()
+
+	value := p.next()
+	if value.kind != tokenWord && value.kind != tokenString {
+		return nil, &Error{value.pos, fmt.Sprintf("expected value after '%s' got %s", op.value, value)}
+	}
+
+	if !f.kind.supports(op.value) {
+		return nil, &Error{op.pos, fmt.Sprintf("operator '%s' can not be used with %s field '%s'",
+			op.value, f.kind, name.value)}
+	}
+
+	return f.compare(p, op.value, value)
+}
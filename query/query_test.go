@@ -0,0 +1,140 @@
+package query_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/credhub"
+	. "github.com/cloudfoundry-community/carousel/query"
+	"github.com/cloudfoundry-community/carousel/state"
+)
+
+var _ = Describe("ParseAt", func() {
+	var (
+		now         time.Time
+		credentials state.Credentials
+	)
+
+	credential := func(name, id string, ct credhub.CredentialType, created, expires time.Duration,
+		deployments ...*state.Deployment) *state.Credential {
+		createdAt := now.Add(-created)
+		c := &state.Credential{
+			Credential: &credhub.Credential{
+				ID:               id,
+				Name:             name,
+				Type:             ct,
+				VersionCreatedAt: &createdAt,
+			},
+			Deployments: deployments,
+			Path:        &state.Path{Name: name, Deployments: deployments},
+			Latest:      true,
+		}
+		c.Path.Versions = state.Credentials{c}
+		if expires != 0 {
+			expiryDate := now.Add(expires)
+			c.ExpiryDate = &expiryDate
+		}
+		return c
+	}
+
+	selected := func(expr string) []string {
+		filter, err := ParseAt(expr, now)
+		Expect(err).ToNot(HaveOccurred())
+
+		out := make([]string, 0)
+		for _, c := range credentials.Select(filter) {
+			out = append(out, c.ID)
+		}
+		return out
+	}
+
+	BeforeEach(func() {
+		now = time.Now()
+		day := 24 * time.Hour
+		cf := &state.Deployment{Name: "cf-prod"}
+		diego := &state.Deployment{Name: "diego"}
+
+		ca := credential("/bosh/cf/diego_ca", "ca", credhub.Certificate, 400*day, 300*day, cf, diego)
+		ca.CertificateAuthority = true
+		rep := credential("/bosh/cf/rep_cert", "rep", credhub.Certificate, 300*day, 30*day, cf)
+		rep.SignedBy = ca
+		old := credential("/bosh/cf/rep_cert", "rep-old", credhub.Certificate, 700*day, -day)
+		old.SignedBy = ca
+		old.Transitional = true
+		old.Latest = false
+		ca.Signs = state.Credentials{rep, old}
+		pwd := credential("/bosh/diego/admin_password", "pwd", credhub.Password, 10*day, 0, diego)
+
+		credentials = state.Credentials{ca, rep, old, pwd}
+	})
+
+	DescribeTable("selecting credentials",
+		func(expr string, expected ...string) {
+			Expect(selected(expr)).To(Equal(expected))
+		},
+		Entry("equality", "type = password", "pwd"),
+		Entry("inequality", "type != certificate", "pwd"),
+		Entry("quoted values", `name = "/bosh/cf/diego_ca"`, "ca"),
+		Entry("globs", "deployment glob cf-*", "ca", "rep"),
+		Entry("regexes", `name ~ "_(cert|password)$"`, "rep", "rep-old", "pwd"),
+		Entry("negated regexes", `name !~ "cert"`, "ca", "pwd"),
+		Entry("unknown update modes", "update_mode = unknown", "ca", "rep", "rep-old", "pwd"),
+		Entry("update modes", "update_mode = no-overwrite"),
+		Entry("boolean fields", "transitional", "rep-old"),
+		Entry("boolean comparisons", "latest = false", "rep-old"),
+		Entry("durations", "expires_in < 60d", "rep", "rep-old"),
+		Entry("durations the other way", "age >= 1y", "ca", "rep-old"),
+		Entry("numbers", "pending_deploys > 0"),
+		Entry("and", "type = certificate and not ca", "rep", "rep-old"),
+		Entry("or", "ca || type = password", "ca", "pwd"),
+		Entry("precedence of and over or", "ca or transitional and latest", "ca"),
+		Entry("parentheses", "(ca or transitional) and latest", "ca"),
+		Entry("signed_by a path", "signed_by /bosh/cf/diego_ca", "rep", "rep-old"),
+		Entry("signed_by a glob", "signed_by '/bosh/*/*_ca'", "rep", "rep-old"),
+		Entry("signs an expression", "signs(transitional)", "ca"),
+		Entry("the example from the docs",
+			"type = certificate and deployment glob cf-* and signed_by /bosh/cf/diego_ca "+
+				"and expires_in < 60d and not transitional", "rep"),
+	)
+
+	It("selects credentials containing pem encoded certificates", func() {
+		bundle := credential("/bosh/cf/tls_bundle", "bundle", credhub.JSON, time.Hour, 0)
+		bundle.RawValue = []byte(`{"cert":"-----BEGIN CERTIFICATE-----\nMIIB...\n-----END CERTIFICATE-----\n"}`)
+		credentials = append(credentials, bundle)
+		Expect(selected("type = json and contains_pem")).To(Equal([]string{"bundle"}))
+	})
+
+	DescribeTable("detecting comparisons",
+		func(expr string, expected bool) {
+			Expect(HasComparison(expr)).To(Equal(expected))
+		},
+		Entry("comparisons", "type = certificate", true),
+		Entry("globs", "deployment glob cf-*", true),
+		Entry("incomplete comparisons", "expires_in <", true),
+		Entry("boolean fields", "ca and latest", false),
+		Entry("paths", "/bosh/cf/diego_ca", false),
+		Entry("regexes", "diego|uaa", false),
+	)
+
+	DescribeTable("rejecting invalid expressions",
+		func(expr string, pos int, msg string) {
+			_, err := ParseAt(expr, now)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*Error).Pos).To(Equal(pos))
+			Expect(err.Error()).To(ContainSubstring(msg))
+		},
+		Entry("unknown fields", "colour = red", 0, "unknown field 'colour'"),
+		Entry("missing values", "type =", 6, "expected value after '='"),
+		Entry("invalid enum values", "type = cert", 7, "expected one of certificate"),
+		Entry("unsupported operators", "name < foo", 5, "operator '<' can not be used with string field"),
+		Entry("invalid durations", "age > soon", 6, "expected duration"),
+		Entry("invalid regexes", "name ~ '('", 7, "invalid regex"),
+		Entry("unbalanced parentheses", "(ca or latest", 13, "expected ')'"),
+		Entry("trailing tokens", "ca latest", 3, "unexpected 'latest'"),
+		Entry("unterminated strings", `name = "foo`, 7, "unterminated string"),
+		Entry("fields without comparison", "name and ca", 5, "expected operator after 'name'"),
+	)
+})
@@ -4,10 +4,9 @@ package resource
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"strings"
 
 	oc "github.com/cloudboss/ofcourse/ofcourse"
-
-	cstate "github.com/cloudfoundry-community/carousel/state"
 )
 
 type Resource struct{}
@@ -16,28 +15,44 @@ type Resource struct{}
 // This is called when Concourse does its resource checks, or when the `fly check-resource` command is run.
 func (r *Resource) Check(source oc.Source, version oc.Version, env oc.Environment,
 	logger *oc.Logger) ([]oc.Version, error) {
-	initializeFromSource(source, logger)
-
-	deployment := source["deployment"].(string)
-	if deployment == "" {
-		logger.Errorf("deployment flag must be set")
+	selection, err := selectionFromSource(source)
+	if err != nil {
+		logger.Errorf("invalid source configuration: %s", err)
+		return nil, err
 	}
 
-	logger.Infof("Refreshing state for deployment '%s'", deployment)
+	initializeFromSource(source, logger)
+
+	logger.Infof("Refreshing state for deployment '%s'", strings.Join(selection.deployments, "', '"))
 	refresh(logger)
 	logger.Infof("done\n")
 
-	credentials := state.Credentials(cstate.DeploymentFilter(deployment))
+	credentials := state.Credentials(selection.Filters()...)
 	credentials.SortByNameAndCreatedAt()
 
 	deployNeeded := false
 	allVersions := ""
 	for _, cred := range credentials {
-		if cred.PendingDeploys().IncludesName(deployment) {
+		pending := false
+		for _, d := range cred.PendingDeploys().Select(selection.Deployment) {
+			if deferred, window := selection.Deferred(cred, d); deferred {
+				logger.Infof("Deferring deploy of %s to '%s', %s", cred.PathVersion(), d.Name, window)
+				continue
+			}
+			pending = true
+		}
+		if pending {
 			deployNeeded = true
 			allVersions += cred.ID
 		}
 	}
+	for _, d := range state.Deployments().Dangling().Select(selection.Deployment) {
+		for _, ref := range d.Dangling {
+			logger.Warnf("Deployment '%s' references deleted credential %s@%s", d.Name, ref.Name, ref.ID)
+			deployNeeded = true
+			allVersions += d.Name + ref.ID
+		}
+	}
 	versions := []oc.Version{}
 	if deployNeeded {
 		logger.Infof("Found credentials to re-deploy")
@@ -6,9 +6,13 @@ import (
 	// "io/ioutil"
 	// "os"
 	// "path/filepath"
+	"strings"
 	"testing"
-	// oc "github.com/cloudboss/ofcourse/ofcourse"
+
+	oc "github.com/cloudboss/ofcourse/ofcourse"
 	// "github.com/stretchr/testify/assert"
+
+	cstate "github.com/cloudfoundry-community/carousel/state"
 )
 
 var (
@@ -165,3 +169,93 @@ func TestCheck(t *testing.T) {
 	// 	assert.Equal(t, tc.err, err)
 	// }
 }
+
+func TestSelectionFromSourceWhere(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		sourceIn oc.Source
+		err      string
+	}{
+		{
+			"single expression",
+			oc.Source{"deployment": "cf", "where": "type = certificate"},
+			"",
+		},
+		{
+			"list of expressions",
+			oc.Source{"deployment": "cf", "where": []interface{}{"ca", "not transitional"}},
+			"",
+		},
+		{
+			"invalid expression",
+			oc.Source{"deployment": "cf", "where": "type ="},
+			"where contains an invalid expression",
+		},
+	}
+
+	for _, tc := range testCases {
+		_, err := selectionFromSource(tc.sourceIn)
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%s: unexpected error: %s", tc.name, err)
+		case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+			t.Errorf("%s: expected error containing %q, got: %v", tc.name, tc.err, err)
+		}
+	}
+}
+
+func TestCheckInvalidSource(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		sourceIn oc.Source
+		err      string
+	}{
+		{
+			"missing deployment",
+			oc.Source{},
+			"deployment must be set",
+		},
+		{
+			"deployment of the wrong type",
+			oc.Source{"deployment": 42},
+			"deployment must be a string or a list of strings",
+		},
+	}
+
+	for _, tc := range testCases {
+		r := Resource{}
+		versions, err := r.Check(tc.sourceIn, nil, oc.NewEnvironment(), oc.NewLogger(oc.SilentLevel))
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected error containing %q, got: %v", tc.name, tc.err, err)
+		}
+		if versions != nil {
+			t.Errorf("%s: expected no versions, got: %v", tc.name, versions)
+		}
+	}
+}
+
+func TestSelectionFromSource(t *testing.T) {
+	s, err := selectionFromSource(oc.Source{
+		"deployment":          []interface{}{"cf", "cf-*"},
+		"exclude_deployments": "cf-canary",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var testCases = []struct {
+		deployment string
+		selected   bool
+	}{
+		{"cf", true},
+		{"cf-iso", true},
+		{"cf-canary", false},
+		{"concourse", false},
+	}
+
+	for _, tc := range testCases {
+		if selected := s.Deployment(&cstate.Deployment{Name: tc.deployment}); selected != tc.selected {
+			t.Errorf("deployment %s: expected selected %t, got %t", tc.deployment, tc.selected, selected)
+		}
+	}
+}
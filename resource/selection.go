@@ -0,0 +1,234 @@
+package resource
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	oc "github.com/cloudboss/ofcourse/ofcourse"
+	"gopkg.in/yaml.v2"
+
+	cpolicy "github.com/cloudfoundry-community/carousel/policy"
+	"github.com/cloudfoundry-community/carousel/query"
+	cstate "github.com/cloudfoundry-community/carousel/state"
+)
+
+// selection holds the deployment and path include/exclude options of the
+// resource source:
+//
+//	deployment: cf-*                 # glob, required
+//	exclude_deployments: [cf-canary]
+//	deployment_regex: []
+//	exclude_deployment_regex: []
+//	paths: [/bosh/cf/*]
+//	exclude_paths: [/*/*/uaa_*]
+//	path_regex: []
+//	exclude_path_regex: []
+//	where: type = certificate and not transitional
+//	policy:                          # as the --policy file, uses windows and freezes
+//	  windows: [{name: nightly, cron: 0 22 * * *, duration: 4h}]
+//	now: 2024-12-24T22:00            # evaluate the windows at this time
+type selection struct {
+	deployments              []string
+	excludeDeployments       []string
+	deploymentRegexes        []*regexp.Regexp
+	excludeDeploymentRegexes []*regexp.Regexp
+	paths                    []string
+	excludePaths             []string
+	pathRegexes              []*regexp.Regexp
+	excludePathRegexes       []*regexp.Regexp
+	where                    []cstate.Filter
+	schedule                 cpolicy.Schedule
+	now                      time.Time
+}
+
+func selectionFromSource(source oc.Source) (*selection, error) {
+	var (
+		s   selection
+		err error
+	)
+
+	if s.deployments, err = globsFromSource(source, "deployment"); err != nil {
+		return nil, err
+	}
+	if len(s.deployments) == 0 {
+		return nil, fmt.Errorf("deployment must be set")
+	}
+	if s.excludeDeployments, err = globsFromSource(source, "exclude_deployments"); err != nil {
+		return nil, err
+	}
+	if s.deploymentRegexes, err = regexesFromSource(source, "deployment_regex"); err != nil {
+		return nil, err
+	}
+	if s.excludeDeploymentRegexes, err = regexesFromSource(source, "exclude_deployment_regex"); err != nil {
+		return nil, err
+	}
+	if s.paths, err = globsFromSource(source, "paths"); err != nil {
+		return nil, err
+	}
+	if s.excludePaths, err = globsFromSource(source, "exclude_paths"); err != nil {
+		return nil, err
+	}
+	if s.pathRegexes, err = regexesFromSource(source, "path_regex"); err != nil {
+		return nil, err
+	}
+	if s.excludePathRegexes, err = regexesFromSource(source, "exclude_path_regex"); err != nil {
+		return nil, err
+	}
+	if s.where, err = expressionsFromSource(source, "where"); err != nil {
+		return nil, err
+	}
+	if s.schedule, err = scheduleFromSource(source, "policy"); err != nil {
+		return nil, err
+	}
+	if s.now, err = timeFromSource(source, "now"); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// Deployment reports whether a deployment is selected by the source.
+func (s *selection) Deployment(d *cstate.Deployment) bool {
+	if len(s.deployments) != 0 && !cstate.MatchesGlob(d.Name, s.deployments...) {
+		return false
+	}
+	if len(s.deploymentRegexes) != 0 && !cstate.MatchesRegex(d.Name, s.deploymentRegexes...) {
+		return false
+	}
+	return !cstate.MatchesGlob(d.Name, s.excludeDeployments...) &&
+		!cstate.MatchesRegex(d.Name, s.excludeDeploymentRegexes...)
+}
+
+// Deferred reports whether deploying cred to d has to wait for the next
+// maintenance window, and when it opens.
+func (s *selection) Deferred(cred *cstate.Credential, d *cstate.Deployment) (bool, string) {
+	if s.schedule.Allowed(cred.Name, []string{d.Name}, s.now) {
+		return false, ""
+	}
+	next, found := s.schedule.NextOpen(cred.Name, []string{d.Name}, s.now)
+	if !found {
+		return true, "no maintenance window opens"
+	}
+	return true, fmt.Sprintf("next maintenance window opens %s", next.Format("2006-01-02 15:04 MST"))
+}
+
+func (s *selection) Filters() []cstate.Filter {
+	out := make([]cstate.Filter, 0)
+	if len(s.deployments) != 0 {
+		out = append(out, cstate.DeploymentGlobFilter(s.deployments...))
+	}
+	if len(s.excludeDeployments) != 0 {
+		out = append(out, cstate.NotFilter(cstate.DeploymentGlobFilter(s.excludeDeployments...)))
+	}
+	if len(s.deploymentRegexes) != 0 {
+		out = append(out, cstate.DeploymentRegexFilter(s.deploymentRegexes...))
+	}
+	if len(s.excludeDeploymentRegexes) != 0 {
+		out = append(out, cstate.NotFilter(cstate.DeploymentRegexFilter(s.excludeDeploymentRegexes...)))
+	}
+	if len(s.paths) != 0 {
+		out = append(out, cstate.PathGlobFilter(s.paths...))
+	}
+	if len(s.excludePaths) != 0 {
+		out = append(out, cstate.NotFilter(cstate.PathGlobFilter(s.excludePaths...)))
+	}
+	if len(s.pathRegexes) != 0 {
+		out = append(out, cstate.PathRegexFilter(s.pathRegexes...))
+	}
+	if len(s.excludePathRegexes) != 0 {
+		out = append(out, cstate.NotFilter(cstate.PathRegexFilter(s.excludePathRegexes...)))
+	}
+	return append(out, s.where...)
+}
+
+func stringsFromSource(source oc.Source, key string) ([]string, error) {
+	switch v := source[key].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, i := range v {
+			s, ok := i.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a list of strings", key)
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("%s must be a string or a list of strings", key)
+	}
+}
+
+func globsFromSource(source oc.Source, key string) ([]string, error) {
+	globs, err := stringsFromSource(source, key)
+	if err != nil {
+		return nil, err
+	}
+	return globs, cstate.ValidateGlobs(globs...)
+}
+
+func regexesFromSource(source oc.Source, key string) ([]*regexp.Regexp, error) {
+	exprs, err := stringsFromSource(source, key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s contains an invalid regex: %s got: %s", key, expr, err)
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+func expressionsFromSource(source oc.Source, key string) ([]cstate.Filter, error) {
+	exprs, err := stringsFromSource(source, key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]cstate.Filter, 0, len(exprs))
+	for _, expr := range exprs {
+		filter, err := query.Parse(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s contains an invalid expression: %s got: %s", key, expr, err)
+		}
+		out = append(out, filter)
+	}
+	return out, nil
+}
+
+func scheduleFromSource(source oc.Source, key string) (cpolicy.Schedule, error) {
+	if source[key] == nil {
+		return cpolicy.Schedule{}, nil
+	}
+	data, err := yaml.Marshal(source[key])
+	if err != nil {
+		return cpolicy.Schedule{}, fmt.Errorf("%s must be a policy got: %s", key, err)
+	}
+	p, err := cpolicy.Parse(data)
+	if err != nil {
+		return cpolicy.Schedule{}, fmt.Errorf("%s %s", key, err)
+	}
+	return p.Schedule()
+}
+
+func timeFromSource(source oc.Source, key string) (time.Time, error) {
+	switch v := source[key].(type) {
+	case nil:
+		return time.Now(), nil
+	case string:
+		t, err := cpolicy.ParseTime(v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s must be a date or time got: %s", key, v)
+		}
+		return t, nil
+	default:
+		return time.Time{}, fmt.Errorf("%s must be a date or time", key)
+	}
+}
@@ -2,9 +2,6 @@ package state
 
 import (
 	"time"
-
-	"github.com/cloudfoundry-community/carousel/bosh"
-	"github.com/cloudfoundry-community/carousel/credhub"
 )
 
 //
@@ -20,72 +17,24 @@ const (
 	CleanUp
 	MarkTransitional
 	UnMarkTransitional
+	StagePrevious
+	RetirePrevious
 )
 
 type RegenerationCriteria struct {
 	OlderThan        time.Time
 	ExpiresBefore    time.Time
 	IgnoreUpdateMode bool
+	// Rules decide the next action, DefaultRules when empty
+	Rules Rules
 }
 
+// NextAction returns the action decided by the first of the criteria's
+// rules which applies to cred, or None when none of them does.
 func (cred *Credential) NextAction(r RegenerationCriteria) Action {
-	for _, ct := range []credhub.CredentialType{credhub.JSON, credhub.Value} {
-		if cred.Type == ct {
-			return None
-		}
-	}
-
-	if !r.IgnoreUpdateMode && cred.Path.VariableDefinition != nil &&
-		cred.Path.VariableDefinition.UpdateMode == bosh.NoOverwrite {
-		return NoOverwrite
-	}
-
-	if cred.Signing != nil && *cred.Signing {
-		latest, found := cred.Path.Versions.Find(LatestFilter())
-		if found && latest.Transitional && latest.Active() {
-			return MarkTransitional
-		}
-	}
-
-	if cred.Latest && cred.SignedBy != nil {
-		latestCa, foundCa := cred.SignedBy.Path.Versions.Find(LatestFilter())
-		if foundCa && !latestCa.Transitional && latestCa.Active() && cred.SignedBy != latestCa {
-			return Regenerate
-		}
-	}
-
-	if cred.Latest && cred.ExpiryDate != nil &&
-		cred.ExpiryDate.Before(r.ExpiresBefore) {
-		if cred.SignedBy == nil {
-			return Regenerate
-		} else if !cred.SignedBy.ExpiryDate.Before(r.ExpiresBefore) {
-			return Regenerate
-		} else {
-			return None
-		}
+	rules := r.Rules
+	if len(rules) == 0 {
+		rules = DefaultRules()
 	}
-
-	if cred.Latest && cred.VersionCreatedAt.Before(r.OlderThan) {
-		return Regenerate
-	}
-
-	if cred.Latest && len(cred.PendingDeploys()) != 0 &&
-		!(cred.Type == credhub.Certificate &&
-			cred.SignedBy == nil &&
-			len(cred.ReferencedBy) == 0) {
-		return BoshDeploy
-	}
-
-	if cred.Transitional && !cred.Latest {
-		signing, found := cred.Path.Versions.Find(SigningFilter())
-		if found && len(signing.PendingDeploys()) == 0 {
-			return UnMarkTransitional
-		}
-	}
-
-	if !cred.Active() && cred.Type == credhub.Certificate {
-		return CleanUp
-	}
-
-	return None
+	return rules.NextAction(cred, r)
 }
@@ -8,9 +8,9 @@ import (
 	"fmt"
 )
 
-const _ActionName = "NoneNoOverwriteBoshDeployRegenerateCleanUpMarkTransitionalUnMarkTransitional"
+const _ActionName = "NoneNoOverwriteBoshDeployRegenerateCleanUpMarkTransitionalUnMarkTransitionalStagePreviousRetirePrevious"
 
-var _ActionIndex = [...]uint8{0, 4, 15, 25, 35, 42, 58, 76}
+var _ActionIndex = [...]uint8{0, 4, 15, 25, 35, 42, 58, 76, 89, 103}
 
 func (i Action) String() string {
 	if i < 0 || i >= Action(len(_ActionIndex)-1) {
@@ -19,16 +19,18 @@ func (i Action) String() string {
 	return _ActionName[_ActionIndex[i]:_ActionIndex[i+1]]
 }
 
-var _ActionValues = []Action{0, 1, 2, 3, 4, 5, 6}
+var _ActionValues = []Action{0, 1, 2, 3, 4, 5, 6, 7, 8}
 
 var _ActionNameToValueMap = map[string]Action{
-	_ActionName[0:4]:   0,
-	_ActionName[4:15]:  1,
-	_ActionName[15:25]: 2,
-	_ActionName[25:35]: 3,
-	_ActionName[35:42]: 4,
-	_ActionName[42:58]: 5,
-	_ActionName[58:76]: 6,
+	_ActionName[0:4]:    0,
+	_ActionName[4:15]:   1,
+	_ActionName[15:25]:  2,
+	_ActionName[25:35]:  3,
+	_ActionName[35:42]:  4,
+	_ActionName[42:58]:  5,
+	_ActionName[58:76]:  6,
+	_ActionName[76:89]:  7,
+	_ActionName[89:103]: 8,
 }
 
 // ActionString retrieves an enum value from the enum constants string name.
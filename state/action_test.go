@@ -327,4 +327,139 @@ var _ = Describe("Credential", func() {
 			})
 		})
 	})
+
+	Describe("NextAction for a three level chain", func() {
+		var (
+			state       State
+			root1       *credhub.Credential
+			root2       *credhub.Credential
+			inter1      *credhub.Credential
+			inter2      *credhub.Credential
+			leaf1       *credhub.Credential
+			leaf2       *credhub.Credential
+			credentials []*credhub.Credential
+			deployed    []*credhub.Credential
+		)
+
+		// nextActions refreshes the state with the credentials deployed to
+		// a single deployment and returns the next action per version id
+		nextActions := func() map[string]Action {
+			Expect(state.Update(credentials, variablesOf("app", deployed))).To(Succeed())
+
+			out := make(map[string]Action)
+			for _, c := range state.Credentials() {
+				out[c.ID] = c.NextAction(criteria)
+			}
+			return out
+		}
+
+		BeforeEach(func() {
+			f := newCertFactory()
+			t0 := time.Now().Add(-time.Hour)
+			criteria.OlderThan = t0.Add(-time.Hour)
+
+			state = NewState()
+			root1 = f.certificate("/root", "root-v1", true, nil, t0)
+			inter1 = f.certificate("/intermediate", "intermediate-v1", true, root1, t0.Add(time.Minute))
+			leaf1 = f.certificate("/leaf", "leaf-v1", false, inter1, t0.Add(2*time.Minute))
+			root2 = f.certificate("/root", "root-v2", true, nil, t0.Add(10*time.Minute))
+			inter2 = f.certificate("/intermediate", "intermediate-v2", true, root2, t0.Add(20*time.Minute))
+			leaf2 = f.certificate("/leaf", "leaf-v2", false, inter2, t0.Add(30*time.Minute))
+
+			// CredHub concatenates transitional ca's into the ca of signed certificates
+			root2.Transitional = true
+			inter1.Ca = append(inter1.Ca, root2.Certificate)
+		})
+
+		It("rotates the root, then the intermediate and then the leaf", func() {
+			By("staging a new transitional root")
+			credentials = []*credhub.Credential{root1, inter1, leaf1, root2}
+			deployed = []*credhub.Credential{root1, inter1, leaf1}
+			Expect(nextActions()).To(Equal(map[string]Action{
+				"root-v1": None, "root-v2": BoshDeploy,
+				"intermediate-v1": None, "leaf-v1": None,
+			}))
+
+			By("deploying the new root")
+			deployed = []*credhub.Credential{root2, inter1, leaf1}
+			Expect(nextActions()).To(Equal(map[string]Action{
+				"root-v1": MarkTransitional, "root-v2": None,
+				"intermediate-v1": None, "leaf-v1": None,
+			}))
+
+			By("swapping the signing root")
+			root1.Transitional, root2.Transitional = true, false
+			Expect(nextActions()).To(Equal(map[string]Action{
+				"root-v1": None, "root-v2": None,
+				"intermediate-v1": Regenerate, "leaf-v1": None,
+			}))
+
+			By("staging a new transitional intermediate")
+			inter2.Transitional = true
+			inter2.Ca = append(inter2.Ca, root1.Certificate)
+			leaf1.Ca = append(leaf1.Ca, inter2.Certificate)
+			credentials = append(credentials, inter2)
+			Expect(nextActions()).To(Equal(map[string]Action{
+				"root-v1": None, "root-v2": None,
+				"intermediate-v1": None, "intermediate-v2": BoshDeploy,
+				"leaf-v1": None,
+			}))
+
+			By("deploying the new intermediate")
+			deployed = []*credhub.Credential{root2, inter2, leaf1}
+			Expect(nextActions()).To(Equal(map[string]Action{
+				"root-v1": None, "root-v2": None,
+				"intermediate-v1": MarkTransitional, "intermediate-v2": None,
+				"leaf-v1": None,
+			}))
+
+			By("swapping the signing intermediate")
+			inter1.Transitional, inter2.Transitional = true, false
+			Expect(nextActions()).To(Equal(map[string]Action{
+				"root-v1": None, "root-v2": None,
+				"intermediate-v1": None, "intermediate-v2": None,
+				"leaf-v1": Regenerate,
+			}))
+
+			By("regenerating the leaf")
+			leaf2.Ca = append(leaf2.Ca, inter1.Certificate)
+			credentials = append(credentials, leaf2)
+			Expect(nextActions()).To(Equal(map[string]Action{
+				"root-v1": None, "root-v2": None,
+				"intermediate-v1": None, "intermediate-v2": None,
+				"leaf-v1": None, "leaf-v2": BoshDeploy,
+			}))
+
+			By("deploying the new leaf")
+			deployed = []*credhub.Credential{root2, inter2, leaf2}
+			Expect(nextActions()).To(Equal(map[string]Action{
+				"root-v1": UnMarkTransitional, "root-v2": None,
+				"intermediate-v1": UnMarkTransitional, "intermediate-v2": None,
+				"leaf-v1": CleanUp, "leaf-v2": None,
+			}))
+		})
+
+		It("does not regenerate an expiring leaf before its intermediate", func() {
+			root1.Transitional, root2.Transitional = true, false
+			credentials = []*credhub.Credential{root1, inter1, leaf1, root2}
+			deployed = []*credhub.Credential{root2, inter1, leaf1}
+			criteria.ExpiresBefore = leaf1.ExpiryDate.Add(time.Hour)
+
+			actions := nextActions()
+			Expect(actions["intermediate-v1"]).To(Equal(Regenerate))
+			Expect(actions["leaf-v1"]).To(Equal(None))
+		})
+
+		It("keeps the old root transitional while the old intermediate is deployed", func() {
+			root1.Transitional = true
+			root2.Transitional = false
+			inter2.Ca = append(inter2.Ca, root1.Certificate)
+			credentials = []*credhub.Credential{root1, inter1, leaf1, root2, inter2}
+			deployed = []*credhub.Credential{root2, inter1, leaf1}
+
+			actions := nextActions()
+			Expect(actions["root-v1"]).To(Equal(None))
+			Expect(actions["intermediate-v2"]).To(Equal(BoshDeploy))
+		})
+	})
 })
@@ -0,0 +1,155 @@
+package state
+
+import (
+	"sort"
+	"time"
+
+	"github.com/cloudfoundry-community/carousel/credhub"
+)
+
+//
+//go:generate go run github.com/alvaroloes/enumer -type=ChangeKind -json -transform=snake
+
+type ChangeKind int
+
+const (
+	VersionAdded ChangeKind = iota
+	VersionDeleted
+	TransitionalSet
+	TransitionalUnset
+	Deployed
+	Undeployed
+)
+
+// SnapshotRecord is what is kept of a Snapshot to compare it with a later
+// one. It does not contain any credential values so it can be safely
+// written to disk.
+type SnapshotRecord struct {
+	UpdatedAt time.Time       `json:"updated_at"`
+	Versions  []VersionRecord `json:"versions"`
+}
+
+type VersionRecord struct {
+	ID               string                 `json:"id"`
+	Name             string                 `json:"name"`
+	Type             credhub.CredentialType `json:"type"`
+	VersionCreatedAt *time.Time             `json:"version_created_at"`
+	Transitional     bool                   `json:"transitional,omitempty"`
+	Deployments      []string               `json:"deployments"`
+}
+
+type Change struct {
+	Kind       ChangeKind `json:"kind"`
+	Name       string     `json:"name"`
+	ID         string     `json:"id"`
+	Deployment string     `json:"deployment,omitempty"`
+}
+
+type ChangeLog struct {
+	Before  time.Time `json:"before"`
+	After   time.Time `json:"after"`
+	Changes []Change  `json:"changes"`
+}
+
+// Record returns the SnapshotRecord of a Snapshot.
+func Record(s Snapshot) SnapshotRecord {
+	out := SnapshotRecord{
+		UpdatedAt: s.UpdatedAt(),
+		Versions:  make([]VersionRecord, 0),
+	}
+	for _, c := range s.Credentials() {
+		deployments := make([]string, 0, len(c.Deployments))
+		for _, d := range c.Deployments {
+			deployments = append(deployments, d.Name)
+		}
+		out.Versions = append(out.Versions, VersionRecord{
+			ID:               c.ID,
+			Name:             c.Name,
+			Type:             c.Type,
+			VersionCreatedAt: c.VersionCreatedAt,
+			Transitional:     c.Transitional,
+			Deployments:      deployments,
+		})
+	}
+	return out
+}
+
+// Compare returns the changes needed to get from before to after: added and
+// deleted versions, flipped transitional flags and versions which have been
+// deployed to or removed from deployments. Changes are ordered by path,
+// version and kind.
+func Compare(before, after SnapshotRecord) ChangeLog {
+	out := ChangeLog{
+		Before:  before.UpdatedAt,
+		After:   after.UpdatedAt,
+		Changes: make([]Change, 0),
+	}
+
+	previous := make(map[string]VersionRecord)
+	for _, v := range before.Versions {
+		previous[v.ID] = v
+	}
+
+	for _, v := range after.Versions {
+		old, found := previous[v.ID]
+		if !found {
+			out.Changes = append(out.Changes, Change{Kind: VersionAdded, Name: v.Name, ID: v.ID})
+			old = VersionRecord{ID: v.ID, Name: v.Name}
+		}
+		delete(previous, v.ID)
+
+		switch {
+		case v.Transitional && !old.Transitional:
+			out.Changes = append(out.Changes, Change{Kind: TransitionalSet, Name: v.Name, ID: v.ID})
+		case !v.Transitional && old.Transitional:
+			out.Changes = append(out.Changes, Change{Kind: TransitionalUnset, Name: v.Name, ID: v.ID})
+		}
+
+		out.Changes = append(out.Changes, deploymentChanges(old, v)...)
+	}
+
+	for _, v := range previous {
+		out.Changes = append(out.Changes, deploymentChanges(v, VersionRecord{ID: v.ID, Name: v.Name})...)
+		out.Changes = append(out.Changes, Change{Kind: VersionDeleted, Name: v.Name, ID: v.ID})
+	}
+
+	sort.SliceStable(out.Changes, func(i, j int) bool {
+		a, b := out.Changes[i], out.Changes[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Deployment < b.Deployment
+	})
+
+	return out
+}
+
+func deploymentChanges(before, after VersionRecord) []Change {
+	out := make([]Change, 0)
+	for _, d := range after.Deployments {
+		if !includesString(before.Deployments, d) {
+			out = append(out, Change{Kind: Deployed, Name: after.Name, ID: after.ID, Deployment: d})
+		}
+	}
+	for _, d := range before.Deployments {
+		if !includesString(after.Deployments, d) {
+			out = append(out, Change{Kind: Undeployed, Name: before.Name, ID: before.ID, Deployment: d})
+		}
+	}
+	return out
+}
+
+func includesString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package state_test
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/bosh"
+	"github.com/cloudfoundry-community/carousel/credhub"
+	. "github.com/cloudfoundry-community/carousel/state"
+)
+
+var _ = Describe("Compare", func() {
+	var (
+		f      *certFactory
+		t0     time.Time
+		before SnapshotRecord
+	)
+
+	record := func(credentials []*credhub.Credential, variables []*bosh.Variable) SnapshotRecord {
+		s := NewState()
+		Expect(s.Update(credentials, variables)).To(Succeed())
+		return Record(s.Snapshot())
+	}
+
+	BeforeEach(func() {
+		f = newCertFactory()
+		t0 = time.Now().Add(-time.Hour)
+	})
+
+	Context("given a rotated certificate authority", func() {
+		var (
+			ca1, ca2, leaf *credhub.Credential
+		)
+
+		BeforeEach(func() {
+			ca1 = f.certificate("/ca", "ca-v1", true, nil, t0)
+			leaf = f.certificate("/leaf", "leaf-v1", false, ca1, t0.Add(time.Minute))
+			ca2 = f.certificate("/ca", "ca-v2", true, nil, t0.Add(2*time.Minute))
+
+			before = record([]*credhub.Credential{ca1, leaf}, []*bosh.Variable{
+				{ID: "ca-v1", Name: "/ca", Deployment: "app"},
+				{ID: "leaf-v1", Name: "/leaf", Deployment: "app"},
+			})
+		})
+
+		It("lists added versions, flipped flags and deployment changes", func() {
+			ca1.Transitional = true
+			after := record([]*credhub.Credential{ca1, leaf, ca2}, []*bosh.Variable{
+				{ID: "ca-v2", Name: "/ca", Deployment: "app"},
+				{ID: "leaf-v1", Name: "/leaf", Deployment: "app"},
+				{ID: "leaf-v1", Name: "/leaf", Deployment: "other"},
+			})
+
+			Expect(Compare(before, after).Changes).To(Equal([]Change{
+				{Kind: TransitionalSet, Name: "/ca", ID: "ca-v1"},
+				{Kind: Undeployed, Name: "/ca", ID: "ca-v1", Deployment: "app"},
+				{Kind: VersionAdded, Name: "/ca", ID: "ca-v2"},
+				{Kind: Deployed, Name: "/ca", ID: "ca-v2", Deployment: "app"},
+				{Kind: Deployed, Name: "/leaf", ID: "leaf-v1", Deployment: "other"},
+			}))
+		})
+
+		It("lists deleted versions", func() {
+			after := record([]*credhub.Credential{leaf}, []*bosh.Variable{
+				{ID: "leaf-v1", Name: "/leaf", Deployment: "app"},
+			})
+
+			Expect(Compare(before, after).Changes).To(Equal([]Change{
+				{Kind: VersionDeleted, Name: "/ca", ID: "ca-v1"},
+				{Kind: Undeployed, Name: "/ca", ID: "ca-v1", Deployment: "app"},
+			}))
+		})
+
+		It("finds no changes in an unchanged state", func() {
+			Expect(Compare(before, before).Changes).To(BeEmpty())
+		})
+
+		It("survives a round trip through json", func() {
+			data, err := json.Marshal(before)
+			Expect(err).ToNot(HaveOccurred())
+
+			var loaded SnapshotRecord
+			Expect(json.Unmarshal(data, &loaded)).To(Succeed())
+			Expect(Compare(loaded, before).Changes).To(BeEmpty())
+			Expect(loaded.UpdatedAt.Equal(before.UpdatedAt)).To(BeTrue())
+		})
+	})
+})
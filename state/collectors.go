@@ -4,6 +4,9 @@ type Collector func(c *Credential) Credentials
 
 func SignedByCollector() Collector {
 	return func(c *Credential) Credentials {
+		if c.SignedBy == nil {
+			return nil
+		}
 		return Credentials{c.SignedBy}
 	}
 }
@@ -19,3 +22,15 @@ func SibilingsCollector() Collector {
 		return c.Path.Versions
 	}
 }
+
+func ReferencesCollector() Collector {
+	return func(c *Credential) Credentials {
+		return c.References
+	}
+}
+
+func ReferencedByCollector() Collector {
+	return func(c *Credential) Credentials {
+		return c.ReferencedBy
+	}
+}
@@ -21,14 +21,18 @@ func (c *Credential) MarshalJSON() ([]byte, error) {
 		updateMode = c.Path.VariableDefinition.UpdateMode
 	}
 
-	c.RawValue = nil // don't leak raw value
+	// don't leak raw value, copy as credentials are shared between readers
+	cred := *c.Credential
+	cred.RawValue = nil
 	type Alias Credential
+	alias := Alias(*c)
+	alias.Credential = &cred
 	return json.Marshal(&struct {
 		*Alias
 		DeploymentsList []string        `json:"deployments"`
 		UpdateMode      bosh.UpdateMode `json:"update_mode"`
 	}{
-		Alias:           (*Alias)(c),
+		Alias:           &alias,
 		DeploymentsList: deployments,
 		UpdateMode:      updateMode,
 	})
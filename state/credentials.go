@@ -4,20 +4,8 @@ import (
 	"sort"
 )
 
-func (s *state) Credentials(filters ...Filter) Credentials {
-	creds := s.credentials.Select(func(_, v interface{}) bool {
-		for _, fn := range filters {
-			if !fn(v.(*Credential)) {
-				return false
-			}
-		}
-		return true
-	})
-	out := make(Credentials, 0, creds.Size())
-	for _, cert := range creds.Values() {
-		out = append(out, cert.(*Credential))
-	}
-	return out
+func (s *snapshot) Credentials(filters ...Filter) Credentials {
+	return s.sortedCredentials.Select(filters...)
 }
 
 func (creds Credentials) Collect(fn Collector) Credentials {
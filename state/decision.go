@@ -0,0 +1,104 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Decision is the next action for a credential together with the rule which
+// decided it and the values that rule compared.
+type Decision struct {
+	Action Action  `json:"action"`
+	Rule   string  `json:"rule,omitempty"`
+	Values []Value `json:"values,omitempty"`
+}
+
+// Value is a named value a rule looked at, e.g. the expiry date of a
+// certificate or the ExpiresBefore criteria it was compared with.
+type Value struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ValuesFunc returns the values a rule compares for cred.
+type ValuesFunc func(cred *Credential, r RegenerationCriteria) []Value
+
+// NewExplainedRule returns a rule which also reports the values it compared
+// in its decisions.
+func NewExplainedRule(name string, fn RuleFunc, values ValuesFunc) Rule {
+	return &rule{name: name, fn: fn, values: values}
+}
+
+func (d Decision) String() string {
+	if d.Rule == "" {
+		return fmt.Sprintf("%s (no rule applies)", d.Action)
+	}
+	values := make([]string, 0, len(d.Values))
+	for _, v := range d.Values {
+		values = append(values, fmt.Sprintf("%s=%s", v.Name, v.Value))
+	}
+	if len(values) == 0 {
+		return fmt.Sprintf("%s by rule %s", d.Action, d.Rule)
+	}
+	return fmt.Sprintf("%s by rule %s: %s", d.Action, d.Rule, strings.Join(values, ", "))
+}
+
+// Decide returns the decision of the first of the criteria's rules which
+// applies to cred.
+func (cred *Credential) Decide(r RegenerationCriteria) Decision {
+	rules := r.Rules
+	if len(rules) == 0 {
+		rules = DefaultRules()
+	}
+	return rules.Decide(cred, r)
+}
+
+// Decide returns the decision of the first rule which applies to cred, a
+// None decision without rule when none of them does.
+func (rules Rules) Decide(cred *Credential, r RegenerationCriteria) Decision {
+	for _, rule := range rules {
+		action, applies := rule.Evaluate(cred, r)
+		if !applies {
+			continue
+		}
+		decision := Decision{Action: action, Rule: rule.Name()}
+		if explained, ok := rule.(explainer); ok {
+			decision.Values = explained.Values(cred, r)
+		}
+		return decision
+	}
+	return Decision{Action: None}
+}
+
+type explainer interface {
+	Values(cred *Credential, r RegenerationCriteria) []Value
+}
+
+func (r *rule) Values(cred *Credential, criteria RegenerationCriteria) []Value {
+	if r.values == nil {
+		return nil
+	}
+	return r.values(cred, criteria)
+}
+
+func timeValue(name string, t *time.Time) Value {
+	if t == nil {
+		return Value{Name: name, Value: "none"}
+	}
+	return Value{Name: name, Value: t.Format(time.RFC3339)}
+}
+
+func credentialValue(name string, c *Credential) Value {
+	if c == nil {
+		return Value{Name: name, Value: "none"}
+	}
+	return Value{Name: name, Value: c.PathVersion()}
+}
+
+func deploymentsValue(name string, d Deployments) Value {
+	if len(d) == 0 {
+		return Value{Name: name, Value: "none"}
+	}
+	return Value{Name: name, Value: d.String()}
+}
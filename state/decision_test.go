@@ -0,0 +1,73 @@
+package state_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/credhub"
+	. "github.com/cloudfoundry-community/carousel/state"
+)
+
+var _ = Describe("Decide", func() {
+	var (
+		credential *Credential
+		criteria   RegenerationCriteria
+		createdAt  time.Time
+	)
+
+	BeforeEach(func() {
+		createdAt = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		credential = &Credential{
+			Credential: &credhub.Credential{
+				ID:               "v1",
+				Name:             "/db_password",
+				Type:             credhub.Password,
+				VersionCreatedAt: &createdAt,
+			},
+			Latest: true,
+			Path:   &Path{Deployments: Deployments{{Name: "cf"}}},
+		}
+		credential.Path.Versions = Credentials{credential}
+		criteria = RegenerationCriteria{OlderThan: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	})
+
+	It("reports the rule and the values it compared", func() {
+		decision := credential.Decide(criteria)
+		Expect(decision).To(Equal(Decision{
+			Action: Regenerate,
+			Rule:   "age",
+			Values: []Value{
+				{Name: "version_created_at", Value: "2020-01-01T00:00:00Z"},
+				{Name: "older_than", Value: "2021-01-01T00:00:00Z"},
+			},
+		}))
+		Expect(decision.String()).To(Equal(
+			"Regenerate by rule age: version_created_at=2020-01-01T00:00:00Z, older_than=2021-01-01T00:00:00Z"))
+	})
+
+	It("reports the pending deployments", func() {
+		criteria.OlderThan = createdAt
+		decision := credential.Decide(criteria)
+		Expect(decision.Action).To(Equal(BoshDeploy))
+		Expect(decision.Rule).To(Equal("pending-deploy"))
+		Expect(decision.Values).To(ContainElement(Value{Name: "pending_deploys", Value: "cf"}))
+	})
+
+	It("reports rules without values", func() {
+		criteria.Rules = Rules{NewRule("always", func(*Credential, RegenerationCriteria) (Action, bool) {
+			return CleanUp, true
+		})}
+		Expect(credential.Decide(criteria)).To(Equal(Decision{Action: CleanUp, Rule: "always"}))
+	})
+
+	It("decides None without rule when no rule applies", func() {
+		criteria.Rules = Rules{NewRule("nothing", func(*Credential, RegenerationCriteria) (Action, bool) {
+			return Regenerate, false
+		})}
+		decision := credential.Decide(criteria)
+		Expect(decision).To(Equal(Decision{Action: None}))
+		Expect(decision.String()).To(Equal("None (no rule applies)"))
+	})
+})
@@ -0,0 +1,108 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry-community/carousel/credhub"
+)
+
+// DualCredentialRule rotates the user and password credentials matching
+// filter without downtime, by keeping the previous value in a companion path
+// (the name of the credential followed by suffix) which BOSH manifests can
+// reference next to the current one, e.g. ((db_password)) and
+// ((db_password_previous)). A rotation goes through these phases:
+//
+//   - StagePrevious copies the current value into a missing companion
+//   - Regenerate introduces a new value (decided by the other rules)
+//   - BoshDeploy deploys the new value, the previous one is still accepted
+//   - RetirePrevious copies the new value into the companion once it is
+//     deployed everywhere, after which the companion is deployed
+//
+// The companion itself is never regenerated.
+func DualCredentialRule(suffix string, filter Filter) Rule {
+	return NewExplainedRule("dual-credential", func(cred *Credential, r RegenerationCriteria) (Action, bool) {
+		if cred.Type != credhub.Password && cred.Type != credhub.User {
+			return None, false
+		}
+
+		if primary, found := cred.Path.primary(suffix); found && filter(primary) {
+			if cred.pendingDeploy() {
+				return BoshDeploy, true
+			}
+			return None, true
+		}
+
+		if !cred.Latest || !filter(cred) {
+			return None, false
+		}
+
+		companion, found := cred.Path.companion(suffix)
+		if !found {
+			return StagePrevious, true
+		}
+
+		if !sameValue(cred, companion) {
+			if cred.pendingDeploy() {
+				return BoshDeploy, true
+			}
+			return RetirePrevious, true
+		}
+
+		// wait for the previous value to be deployed before introducing
+		// a new one
+		if companion.pendingDeploy() {
+			return None, true
+		}
+
+		return None, false
+	}, func(cred *Credential, r RegenerationCriteria) []Value {
+		if primary, found := cred.Path.primary(suffix); found {
+			return []Value{
+				credentialValue("primary", primary),
+				deploymentsValue("pending_deploys", cred.PendingDeploys()),
+			}
+		}
+		companion, found := cred.Path.companion(suffix)
+		if !found {
+			return []Value{{Name: "companion", Value: "none"}}
+		}
+		return []Value{
+			credentialValue("companion", companion),
+			{Name: "same_value", Value: fmt.Sprint(sameValue(cred, companion))},
+			deploymentsValue("pending_deploys", cred.PendingDeploys()),
+			deploymentsValue("companion_pending_deploys", companion.PendingDeploys()),
+		}
+	})
+}
+
+// CompanionName returns the name of the path holding the previous value of
+// a dual credential.
+func CompanionName(name, suffix string) string {
+	return name + suffix
+}
+
+// companion returns the latest version of the companion path of p.
+func (p *Path) companion(suffix string) (*Credential, bool) {
+	companion, found := p.paths[CompanionName(p.Name, suffix)]
+	if !found || len(companion.Versions) == 0 {
+		return nil, false
+	}
+	return companion.Versions[0], true
+}
+
+// primary returns the latest version of the path p is the companion of.
+func (p *Path) primary(suffix string) (*Credential, bool) {
+	if !strings.HasSuffix(p.Name, suffix) {
+		return nil, false
+	}
+	primary, found := p.paths[strings.TrimSuffix(p.Name, suffix)]
+	if !found || len(primary.Versions) == 0 {
+		return nil, false
+	}
+	return primary.Versions[0], true
+}
+
+func sameValue(a, b *Credential) bool {
+	return a.Type == b.Type && a.Username == b.Username && a.Password == b.Password
+}
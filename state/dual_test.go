@@ -0,0 +1,95 @@
+package state_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/credhub"
+	. "github.com/cloudfoundry-community/carousel/state"
+)
+
+var _ = Describe("DualCredentialRule", func() {
+	var (
+		state       State
+		criteria    RegenerationCriteria
+		t0          time.Time
+		credentials []*credhub.Credential
+		deployed    []*credhub.Credential
+	)
+
+	nextActions := func() map[string]Action {
+		Expect(state.Update(credentials, variablesOf("app", deployed))).To(Succeed())
+
+		out := make(map[string]Action)
+		for _, c := range state.Credentials(LatestFilter()) {
+			out[c.Name] = c.NextAction(criteria)
+		}
+		return out
+	}
+
+	BeforeEach(func() {
+		t0 = time.Now()
+		state = NewState()
+		criteria = RegenerationCriteria{
+			OlderThan:     t0.Add(-time.Hour),
+			ExpiresBefore: t0,
+			Rules: DefaultRules().InsertAfter("update-mode",
+				DualCredentialRule("_previous", NameFilter("/db_password"))),
+		}
+	})
+
+	It("rotates through the previous value", func() {
+		v1 := password("/db_password", "v1", t0.Add(-2*time.Hour))
+		v1.Password = "a"
+		credentials = []*credhub.Credential{v1}
+		deployed = []*credhub.Credential{v1}
+
+		By("staging the previous value")
+		Expect(nextActions()).To(Equal(map[string]Action{"/db_password": StagePrevious}))
+
+		By("introducing a new value")
+		p1 := password("/db_password_previous", "p1", t0.Add(-2*time.Hour))
+		p1.Password = "a"
+		credentials = append(credentials, p1)
+		deployed = append(deployed, p1)
+		Expect(nextActions()).To(Equal(map[string]Action{
+			"/db_password": Regenerate, "/db_password_previous": None,
+		}))
+
+		By("deploying the new value")
+		v2 := password("/db_password", "v2", t0)
+		v2.Password = "b"
+		credentials = append(credentials, v2)
+		Expect(nextActions()).To(Equal(map[string]Action{
+			"/db_password": BoshDeploy, "/db_password_previous": None,
+		}))
+
+		By("retiring the previous value")
+		deployed = []*credhub.Credential{v2, p1}
+		Expect(nextActions()).To(Equal(map[string]Action{
+			"/db_password": RetirePrevious, "/db_password_previous": None,
+		}))
+
+		By("deploying the retired previous value")
+		p2 := password("/db_password_previous", "p2", t0)
+		p2.Password = "b"
+		credentials = append(credentials, p2)
+		Expect(nextActions()).To(Equal(map[string]Action{
+			"/db_password": None, "/db_password_previous": BoshDeploy,
+		}))
+
+		By("finishing the rotation")
+		deployed = []*credhub.Credential{v2, p2}
+		Expect(nextActions()).To(Equal(map[string]Action{
+			"/db_password": None, "/db_password_previous": None,
+		}))
+	})
+
+	It("does not apply to credentials not matching the filter", func() {
+		credentials = []*credhub.Credential{password("/other_password", "o1", t0.Add(-2*time.Hour))}
+		deployed = credentials
+		Expect(nextActions()).To(Equal(map[string]Action{"/other_password": Regenerate}))
+	})
+})
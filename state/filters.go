@@ -1,6 +1,9 @@
 package state
 
 import (
+	"fmt"
+	"path"
+	"regexp"
 	"time"
 
 	"github.com/cloudfoundry-community/carousel/credhub"
@@ -31,6 +34,19 @@ func AnyFilter(fn Collector) Filter {
 	}
 }
 
+// RelatedFilter selects credentials for which fn collects at least one
+// credential matching filter, e.g. certificates signed by a CA matching it.
+func RelatedFilter(fn Collector, filter Filter) Filter {
+	return func(c *Credential) bool {
+		for _, related := range fn(c) {
+			if related != nil && filter(related) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
 func AndFilter(fns ...Filter) Filter {
 	return func(c *Credential) bool {
 		for _, fn := range fns {
@@ -44,7 +60,7 @@ func AndFilter(fns ...Filter) Filter {
 
 func SelfSignedFilter() Filter {
 	return func(c *Credential) bool {
-		return c.SignedBy == nil
+		return c.SignedBy == nil && len(c.Issuers) == 0
 	}
 }
 
@@ -100,6 +116,80 @@ func NameFilter(name string) Filter {
 	}
 }
 
+// DeploymentGlobFilter selects credentials whose path is used by at least
+// one deployment matching any of the given glob patterns (see path.Match).
+func DeploymentGlobFilter(patterns ...string) Filter {
+	return deploymentMatchFilter(func(name string) bool {
+		return MatchesGlob(name, patterns...)
+	})
+}
+
+// DeploymentRegexFilter selects credentials whose path is used by at least
+// one deployment matching any of the given regular expressions.
+func DeploymentRegexFilter(exprs ...*regexp.Regexp) Filter {
+	return deploymentMatchFilter(func(name string) bool {
+		return MatchesRegex(name, exprs...)
+	})
+}
+
+// PathGlobFilter selects credentials whose name matches any of the given glob
+// patterns (see path.Match), e.g. /*/cf/uaa_*.
+func PathGlobFilter(patterns ...string) Filter {
+	return func(c *Credential) bool {
+		return MatchesGlob(c.Name, patterns...)
+	}
+}
+
+// PathRegexFilter selects credentials whose name matches any of the given
+// regular expressions.
+func PathRegexFilter(exprs ...*regexp.Regexp) Filter {
+	return func(c *Credential) bool {
+		return MatchesRegex(c.Name, exprs...)
+	}
+}
+
+func deploymentMatchFilter(match func(string) bool) Filter {
+	return func(c *Credential) bool {
+		for _, d := range c.Path.Deployments {
+			if match(d.Name) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchesGlob reports whether name matches any of the glob patterns.
+// Malformed patterns never match, use ValidateGlobs to catch them early.
+func MatchesGlob(name string, patterns ...string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateGlobs returns an error for the first malformed glob pattern.
+func ValidateGlobs(patterns ...string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid glob pattern: %s got: %s", pattern, err)
+		}
+	}
+	return nil
+}
+
+// MatchesRegex reports whether name matches any of the regular expressions.
+func MatchesRegex(name string, exprs ...*regexp.Regexp) bool {
+	for _, expr := range exprs {
+		if expr.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
 func CertificateAuthorityFilter(expected bool) Filter {
 	return func(c *Credential) bool {
 		return c.CertificateAuthority == expected
@@ -124,6 +214,32 @@ func SignedByFilter(name string) Filter {
 	}
 }
 
+// FlaggedIssuerFilter selects certificates whose issuer is ambiguous or
+// could not be verified.
+func FlaggedIssuerFilter() Filter {
+	return func(c *Credential) bool {
+		return c.IssuerStatus.Flagged()
+	}
+}
+
+// PendingDeployFilter selects latest credentials which deployments using
+// their path have not been deployed with yet.
+func PendingDeployFilter() Filter {
+	return func(c *Credential) bool {
+		return len(c.PendingDeploys()) != 0
+	}
+}
+
+// UnusedFilter selects credential versions nothing depends on anymore: not
+// deployed, not transitional and not signing any certificate.
+func UnusedFilter() Filter {
+	return AndFilter(
+		NotFilter(ActiveFilter()),
+		NotFilter(TransitionalFilter()),
+		NotFilter(AnyFilter(SignsCollector())),
+	)
+}
+
 func References(c *Credential) Filter {
 	return func(c *Credential) bool {
 		return c.References.Includes(c)
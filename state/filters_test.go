@@ -0,0 +1,130 @@
+package state_test
+
+import (
+	"regexp"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/credhub"
+	. "github.com/cloudfoundry-community/carousel/state"
+)
+
+var _ = Describe("Filters", func() {
+	var credentials Credentials
+
+	newCredential := func(name string, deployments ...string) *Credential {
+		path := &Path{Name: name, Deployments: make(Deployments, 0)}
+		for _, d := range deployments {
+			path.Deployments = append(path.Deployments, &Deployment{Name: d})
+		}
+		c := &Credential{
+			Credential: &credhub.Credential{Name: name},
+			Path:       path,
+		}
+		path.Versions = Credentials{c}
+		return c
+	}
+
+	names := func(creds Credentials) []string {
+		out := make([]string, 0)
+		for _, c := range creds {
+			out = append(out, c.Name)
+		}
+		return out
+	}
+
+	BeforeEach(func() {
+		credentials = Credentials{
+			newCredential("/bosh/cf/uaa_ssl", "cf"),
+			newCredential("/bosh/cf/router_ssl", "cf"),
+			newCredential("/bosh/cf-canary/uaa_ssl", "cf-canary"),
+			newCredential("/bosh/concourse/atc_tls", "concourse"),
+			newCredential("/dns_api_tls_ca", "cf", "concourse"),
+		}
+	})
+
+	Describe("DeploymentGlobFilter", func() {
+		It("selects credentials used by matching deployments", func() {
+			Expect(names(credentials.Select(DeploymentGlobFilter("cf-*", "concourse")))).To(Equal([]string{
+				"/bosh/cf-canary/uaa_ssl", "/bosh/concourse/atc_tls", "/dns_api_tls_ca",
+			}))
+		})
+	})
+
+	Describe("DeploymentRegexFilter", func() {
+		It("selects credentials used by matching deployments", func() {
+			Expect(names(credentials.Select(DeploymentRegexFilter(regexp.MustCompile("^cf$"))))).To(Equal([]string{
+				"/bosh/cf/uaa_ssl", "/bosh/cf/router_ssl", "/dns_api_tls_ca",
+			}))
+		})
+	})
+
+	Describe("PathGlobFilter", func() {
+		It("does not match across path segments", func() {
+			Expect(names(credentials.Select(PathGlobFilter("/*/*/uaa_*")))).To(Equal([]string{
+				"/bosh/cf/uaa_ssl", "/bosh/cf-canary/uaa_ssl",
+			}))
+			Expect(credentials.Select(PathGlobFilter("/*_ca"))).To(HaveLen(1))
+		})
+	})
+
+	Describe("PathRegexFilter", func() {
+		It("selects matching credentials", func() {
+			Expect(names(credentials.Select(PathRegexFilter(regexp.MustCompile("_tls(_ca)?$"))))).To(Equal([]string{
+				"/bosh/concourse/atc_tls", "/dns_api_tls_ca",
+			}))
+		})
+	})
+
+	Describe("PendingDeployFilter", func() {
+		It("selects latest credentials not deployed to all deployments of their path", func() {
+			for _, c := range credentials {
+				c.Latest = true
+				c.Deployments = c.Path.Deployments[:1]
+			}
+			credentials[1].Latest, credentials[1].Deployments = false, nil
+			credentials[4].Deployments = nil
+			Expect(names(credentials.Select(PendingDeployFilter()))).To(Equal([]string{"/dns_api_tls_ca"}))
+		})
+	})
+
+	Describe("UnusedFilter", func() {
+		It("selects versions which are not deployed, transitional or signing", func() {
+			for _, c := range credentials {
+				c.Deployments = c.Path.Deployments
+			}
+			credentials[1].Deployments = nil
+			credentials[2].Deployments, credentials[2].Transitional = nil, true
+			credentials[3].Deployments, credentials[3].Signs = nil, Credentials{credentials[0]}
+			Expect(names(credentials.Select(UnusedFilter()))).To(Equal([]string{"/bosh/cf/router_ssl"}))
+		})
+	})
+
+	Context("composed with the existing combinators", func() {
+		It("includes and excludes", func() {
+			filter := AndFilter(
+				DeploymentGlobFilter("cf*"),
+				NotFilter(PathGlobFilter("/*/*/uaa_*")),
+			)
+			Expect(names(credentials.Select(filter))).To(Equal([]string{
+				"/bosh/cf/router_ssl", "/dns_api_tls_ca",
+			}))
+
+			filter = OrFilter(
+				PathGlobFilter("/bosh/concourse/*"),
+				PathRegexFilter(regexp.MustCompile("router")),
+			)
+			Expect(names(credentials.Select(filter))).To(Equal([]string{
+				"/bosh/cf/router_ssl", "/bosh/concourse/atc_tls",
+			}))
+		})
+	})
+
+	Describe("ValidateGlobs", func() {
+		It("rejects malformed patterns", func() {
+			Expect(ValidateGlobs("cf-*", "[")).To(HaveOccurred())
+			Expect(ValidateGlobs("cf-*")).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,171 @@
+package state_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/bosh"
+	"github.com/cloudfoundry-community/carousel/credhub"
+	. "github.com/cloudfoundry-community/carousel/state"
+)
+
+// certFactory creates real x509 certificates for building credential graphs.
+// Every certificate authority gets its own key pair while all leaves share
+// one to keep generating large datasets cheap.
+type certFactory struct {
+	leafKey *ecdsa.PrivateKey
+	keys    map[*x509.Certificate]*ecdsa.PrivateKey
+	serial  int64
+}
+
+func newCertFactory() *certFactory {
+	return &certFactory{
+		leafKey: newKey(),
+		keys:    make(map[*x509.Certificate]*ecdsa.PrivateKey),
+	}
+}
+
+func newKey() *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// certificate creates a certificate credential version, signed by ca or
+// self-signed when ca is nil.
+func (f *certFactory) certificate(name, id string, isCA bool, ca *credhub.Credential,
+	createdAt time.Time) *credhub.Credential {
+	key := f.leafKey
+	if isCA {
+		key = newKey()
+	}
+	return f.certificateWithKey(key, name, id, isCA, ca, createdAt)
+}
+
+// certificateWithKey is like certificate but uses the given key pair, which
+// allows building certificate authority versions sharing the same key.
+func (f *certFactory) certificateWithKey(key *ecdsa.PrivateKey, name, id string, isCA bool,
+	ca *credhub.Credential, createdAt time.Time) *credhub.Credential {
+	f.serial++
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(f.serial),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             createdAt,
+		NotAfter:              createdAt.Add(365 * 24 * time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		SubjectKeyId:          f.subjectKeyId(key, isCA),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+
+	parent, parentKey := template, key
+	cas := make([]*x509.Certificate, 0)
+	if ca != nil {
+		parent, parentKey = ca.Certificate, f.keys[ca.Certificate]
+		cas = append(cas, ca.Certificate)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		panic(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		panic(err)
+	}
+	f.keys[cert] = key
+
+	expiry := cert.NotAfter
+	return &credhub.Credential{
+		ID:                   id,
+		Name:                 name,
+		Type:                 credhub.Certificate,
+		VersionCreatedAt:     &createdAt,
+		CertificateAuthority: isCA,
+		SelfSigned:           ca == nil,
+		ExpiryDate:           &expiry,
+		Certificate:          cert,
+		Ca:                   cas,
+	}
+}
+
+// subjectKeyId is derived from the public key for certificate authorities,
+// like CredHub does, and unique for leaves.
+func (f *certFactory) subjectKeyId(key *ecdsa.PrivateKey, isCA bool) []byte {
+	if isCA {
+		sum := sha1.Sum(elliptic.Marshal(key.Curve, key.X, key.Y))
+		return sum[:]
+	}
+	out := make([]byte, 8)
+	binary.BigEndian.PutUint64(out, uint64(f.serial))
+	return out
+}
+
+// syntheticDataset builds a director with size credentials: certificate
+// authorities with two versions each (the newest one transitional) and leaf
+// certificates signed by the older version, spread over 20 deployments.
+func syntheticDataset(size int) ([]*credhub.Credential, []*bosh.Variable) {
+	const leavesPerCA = 48
+	f := newCertFactory()
+	now := time.Now()
+
+	credentials := make([]*credhub.Credential, 0, size)
+	variables := make([]*bosh.Variable, 0, size)
+
+	for i := 0; len(credentials) < size; i++ {
+		deployment := fmt.Sprintf("deployment-%d", i%20)
+		caName := fmt.Sprintf("/bosh/%s/ca-%d", deployment, i)
+		oldCA := f.certificate(caName, fmt.Sprintf("ca-%d-v1", i), true, nil, now.Add(-time.Hour))
+		newCA := f.certificate(caName, fmt.Sprintf("ca-%d-v2", i), true, nil, now)
+		newCA.Transitional = true
+		credentials = append(credentials, oldCA, newCA)
+		variables = append(variables, &bosh.Variable{
+			ID: oldCA.ID, Name: caName, Deployment: deployment,
+		})
+
+		for j := 0; j < leavesPerCA && len(credentials) < size; j++ {
+			leafName := fmt.Sprintf("/bosh/%s/leaf-%d-%d", deployment, i, j)
+			leaf := f.certificate(leafName, fmt.Sprintf("leaf-%d-%d-v1", i, j), false, oldCA, now)
+			leaf.Ca = append(leaf.Ca, newCA.Certificate)
+			credentials = append(credentials, leaf)
+			variables = append(variables, &bosh.Variable{
+				ID: leaf.ID, Name: leafName, Deployment: deployment,
+			})
+		}
+	}
+
+	return credentials, variables
+}
+
+// password creates a password credential version.
+func password(name, id string, createdAt time.Time) *credhub.Credential {
+	return &credhub.Credential{ID: id, Name: name, Type: credhub.Password, VersionCreatedAt: &createdAt}
+}
+
+// pathNamed returns the path named name in the current snapshot of state.
+func pathNamed(state State, name string) *Path {
+	cred, found := state.Credentials(NameFilter(name)).Find(LatestFilter())
+	Expect(found).To(BeTrue(), "path %s not found", name)
+	return cred.Path
+}
+
+// variablesOf returns the variables of a deployment using credentials.
+func variablesOf(deployment string, credentials []*credhub.Credential) []*bosh.Variable {
+	out := make([]*bosh.Variable, 0, len(credentials))
+	for _, c := range credentials {
+		out = append(out, &bosh.Variable{ID: c.ID, Name: c.Name, Deployment: deployment})
+	}
+	return out
+}
@@ -1,63 +1,120 @@
 package state
 
-import "bytes"
+import (
+	"crypto/x509"
+	"sort"
+)
 
-func (s *state) getCredentialBySubjectKeyId(keyId []byte) (*Credential, bool) {
-	_, foundValue := s.credentials.Find(func(index interface{}, value interface{}) bool {
-		if value.(*Credential).Certificate != nil {
-			return bytes.Compare(value.(*Credential).Certificate.SubjectKeyId, keyId) == 0
-		}
-		return false
+// index sorts the credentials and paths added to the snapshot and builds the
+// subject key ID index used to look up certificate authorities.
+func (s *snapshot) index() {
+	s.sortedCredentials = make(Credentials, 0, len(s.credentials))
+	for _, c := range s.credentials {
+		s.sortedCredentials = append(s.sortedCredentials, c)
+	}
+	sort.Slice(s.sortedCredentials, func(i, j int) bool {
+		return s.sortedCredentials[i].ID < s.sortedCredentials[j].ID
 	})
-	if foundValue != nil {
-		return foundValue.(*Credential), true
+
+	s.sortedPaths = make([]*Path, 0, len(s.paths))
+	for _, p := range s.paths {
+		s.sortedPaths = append(s.sortedPaths, p)
+	}
+	sort.Slice(s.sortedPaths, func(i, j int) bool {
+		return s.sortedPaths[i].Name < s.sortedPaths[j].Name
+	})
+
+	for _, c := range s.sortedCredentials {
+		if c.Certificate == nil {
+			continue
+		}
+		if len(c.Certificate.SubjectKeyId) != 0 {
+			key := string(c.Certificate.SubjectKeyId)
+			s.subjectKeys[key] = append(s.subjectKeys[key], c)
+		}
+		subject := string(c.Certificate.RawSubject)
+		s.subjects[subject] = append(s.subjects[subject], c)
+		s.certificates[string(c.Certificate.Raw)] = c
 	}
-	return nil, false
 }
 
-func (s *state) eachPath(fn func(*Path)) {
-	s.paths.Each(func(_, v interface{}) {
-		fn(v.(*Path))
-	})
+func (s *snapshot) getCredentialsBySubjectKeyId(keyId []byte) Credentials {
+	return s.subjectKeys[string(keyId)]
 }
 
-func (s *state) getPath(name string) (*Path, bool) {
-	i, found := s.paths.Get(name)
-	if found {
-		return i.(*Path), true
+func (s *snapshot) getCredentialBySubjectKeyId(keyId []byte) (*Credential, bool) {
+	creds := s.getCredentialsBySubjectKeyId(keyId)
+	if len(creds) != 0 {
+		return creds[0], true
 	}
 	return nil, false
 }
 
-func (s *state) getOrCreateDeployment(name string) *Deployment {
-	i, found := s.deployments.Get(name)
-	if found {
-		return i.(*Deployment)
+// getCredentialByCertificate finds the credential holding exactly cert,
+// falling back on the first with the same subject key ID.
+func (s *snapshot) getCredentialByCertificate(cert *x509.Certificate) (*Credential, bool) {
+	if c, found := s.certificates[string(cert.Raw)]; found {
+		return c, true
+	}
+	return s.getCredentialBySubjectKeyId(cert.SubjectKeyId)
+}
+
+func (s *snapshot) eachPath(fn func(*Path)) {
+	for _, p := range s.sortedPaths {
+		fn(p)
+	}
+}
+
+func (s *snapshot) getPath(name string) (*Path, bool) {
+	p, found := s.paths[name]
+	return p, found
+}
+
+func (s *snapshot) getOrCreatePath(name string) *Path {
+	if p, found := s.paths[name]; found {
+		return p
+	}
+	p := &Path{
+		Name:        name,
+		Deployments: make(Deployments, 0),
+		paths:       s.paths,
+	}
+	s.paths[name] = p
+	return p
+}
+
+func (s *snapshot) getOrCreateDeployment(name string) *Deployment {
+	if d, found := s.deployments[name]; found {
+		return d
 	}
 	d := &Deployment{
 		Name:     name,
 		Versions: make([]*Credential, 0),
 	}
-	s.deployments.Put(name, d)
+	s.deployments[name] = d
+
+	i := sort.Search(len(s.sortedDeployments), func(i int) bool {
+		return s.sortedDeployments[i].Name >= name
+	})
+	s.sortedDeployments = append(s.sortedDeployments, nil)
+	copy(s.sortedDeployments[i+1:], s.sortedDeployments[i:])
+	s.sortedDeployments[i] = d
 	return d
 }
 
-func (s *state) getCredential(id string) (*Credential, bool) {
-	i, found := s.credentials.Get(id)
-	if found {
-		return i.(*Credential), true
-	}
-	return nil, false
+func (s *snapshot) getCredential(id string) (*Credential, bool) {
+	c, found := s.credentials[id]
+	return c, found
 }
 
-func (s *state) eachCredential(fn func(*Credential)) {
-	s.credentials.Each(func(_, v interface{}) {
-		fn(v.(*Credential))
-	})
+func (s *snapshot) eachCredential(fn func(*Credential)) {
+	for _, c := range s.sortedCredentials {
+		fn(c)
+	}
 }
 
-func (s *state) eachDeployment(fn func(*Deployment)) {
-	s.deployments.Each(func(_, v interface{}) {
-		fn(v.(*Deployment))
-	})
+func (s *snapshot) eachDeployment(fn func(*Deployment)) {
+	for _, d := range s.sortedDeployments {
+		fn(d)
+	}
 }
@@ -0,0 +1,56 @@
+package state
+
+// supersededIssuer is true when cred has been signed by a version of its
+// certificate authority which is no longer the latest, while the latest
+// version has been activated and swapped in as the signing version.
+func (c *Credential) supersededIssuer() bool {
+	if c.SignedBy == nil || c.SignedBy.Path == nil {
+		return false
+	}
+	latestCa, found := c.SignedBy.Path.Versions.Find(LatestFilter())
+	return found && !latestCa.Transitional && latestCa.Active() && c.SignedBy != latestCa
+}
+
+// awaitingIssuerRotation is true when the latest version of any certificate
+// authority above cred in the hierarchy still has to be regenerated because
+// its own issuer has been rotated. Regenerating cred before that would sign
+// it with a certificate authority which is about to be replaced.
+func (c *Credential) awaitingIssuerRotation() bool {
+	seen := make(Credentials, 0)
+	for ca := c.SignedBy; ca != nil && ca.Path != nil && !seen.Includes(ca); {
+		seen = append(seen, ca)
+		latestCa, found := ca.Path.Versions.Find(LatestFilter())
+		if !found {
+			return false
+		}
+		if latestCa.supersededIssuer() {
+			return true
+		}
+		ca = latestCa.SignedBy
+	}
+	return false
+}
+
+// stillIssuing is true while any credential signed by cred, directly or
+// through intermediate certificate authorities, is the latest version of its
+// path or still deployed. Until then the whole chain up to cred must stay
+// trusted, so cred can not stop being transitional yet.
+func (c *Credential) stillIssuing() bool {
+	return c.stillIssuingNotSeenBefore(make(Credentials, 0))
+}
+
+func (c *Credential) stillIssuingNotSeenBefore(credsSeen Credentials) bool {
+	if credsSeen.Includes(c) {
+		return false
+	}
+	seen := append(credsSeen, c)
+	for _, cred := range c.Signs {
+		if cred.Latest || len(cred.Deployments) != 0 {
+			return true
+		}
+		if cred.stillIssuingNotSeenBefore(seen) {
+			return true
+		}
+	}
+	return false
+}
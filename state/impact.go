@@ -0,0 +1,164 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Impact describes everything affected by rotating a path: the paths of
+// credentials signed by it or referencing it in their ca bundle, directly or
+// through intermediate certificate authorities, and the deployments using
+// any of them.
+type Impact struct {
+	Path        *Path
+	Paths       []ImpactedPath // ordered by depth and name
+	Deployments Deployments    // ordered by name
+	Rounds      []DeployRound  // suggested deploy order
+}
+
+// ImpactedPath is a path reached while walking down from the rotated path,
+// Depth is the number of signing or ca bundle links on the longest chain
+// between them.
+type ImpactedPath struct {
+	Path  *Path
+	Depth int
+}
+
+type DeployRound struct {
+	Description string
+	Deployments Deployments
+}
+
+// Impact walks Signs and ReferencedBy across all versions of p to find the
+// credentials and deployments affected by its rotation.
+//
+// Rotating a certificate authority with h levels of certificates below it
+// takes h + 2 deploy rounds: one to distribute the new ca, one per level to
+// deploy the regenerated certificates, which also distributes the ca of the
+// next level, and one to stop trusting the old transitional versions. Any
+// other credential only has to be deployed once. Rounds without deployments
+// are left out.
+func (p *Path) Impact() Impact {
+	out := Impact{
+		Path:        p,
+		Paths:       []ImpactedPath{{Path: p}},
+		Deployments: make(Deployments, 0),
+		Rounds:      make([]DeployRound, 0),
+	}
+
+	seen := map[*Path]bool{p: true}
+	for i := 0; i < len(out.Paths); i++ {
+		for _, child := range impactedBy(out.Paths[i].Path) {
+			if !seen[child] {
+				seen[child] = true
+				out.Paths = append(out.Paths, ImpactedPath{Path: child})
+			}
+		}
+	}
+
+	// A path can only be deployed after everything above it, so its depth is
+	// the longest chain of links to it. Relaxing the depths at most once per
+	// path terminates on cycles, e.g. of cross-signed certificate authorities.
+	index := make(map[*Path]int, len(out.Paths))
+	for i, ip := range out.Paths {
+		index[ip.Path] = i
+	}
+	for n := 1; n < len(out.Paths); n++ {
+		changed := false
+		for _, ip := range out.Paths {
+			for _, child := range impactedBy(ip.Path) {
+				if c := &out.Paths[index[child]]; child != p && c.Depth < ip.Depth+1 {
+					c.Depth, changed = ip.Depth+1, true
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	sort.SliceStable(out.Paths, func(i, j int) bool {
+		if out.Paths[i].Depth != out.Paths[j].Depth {
+			return out.Paths[i].Depth < out.Paths[j].Depth
+		}
+		return out.Paths[i].Path.Name < out.Paths[j].Path.Name
+	})
+
+	height := 0
+	for _, ip := range out.Paths {
+		out.Deployments = appendDeployments(out.Deployments, ip.Path.Deployments...)
+		if ip.Depth > height {
+			height = ip.Depth
+		}
+	}
+	sort.Slice(out.Deployments, func(i, j int) bool {
+		return out.Deployments[i].Name < out.Deployments[j].Name
+	})
+
+	if height == 0 || len(p.Versions) == 0 || !p.Versions[0].CertificateAuthority {
+		out.addRound(fmt.Sprintf("deploy the regenerated %s", p.Name), out.Deployments)
+		return out
+	}
+
+	out.addRound(fmt.Sprintf("deploy the new transitional %s", p.Name), out.deploymentsAtDepth(0, 1))
+	for depth := 1; depth <= height; depth++ {
+		out.addRound(fmt.Sprintf("deploy the credentials regenerated at level %d", depth),
+			out.deploymentsAtDepth(depth, depth+1))
+	}
+	out.addRound("deploy after removing the transitional flag of the old versions", out.Deployments)
+
+	return out
+}
+
+// impactedBy returns the paths of the credentials signed by or referencing
+// any version of p.
+func impactedBy(p *Path) []*Path {
+	out := make([]*Path, 0)
+	for _, version := range p.Versions {
+		for _, c := range append(append(Credentials{}, version.Signs...), version.ReferencedBy...) {
+			if !includesPath(out, c.Path) {
+				out = append(out, c.Path)
+			}
+		}
+	}
+	return out
+}
+
+func includesPath(list []*Path, p *Path) bool {
+	for _, item := range list {
+		if item == p {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *Impact) addRound(description string, deployments Deployments) {
+	if len(deployments) != 0 {
+		i.Rounds = append(i.Rounds, DeployRound{Description: description, Deployments: deployments})
+	}
+}
+
+func (i Impact) deploymentsAtDepth(depths ...int) Deployments {
+	out := make(Deployments, 0)
+	for _, ip := range i.Paths {
+		for _, depth := range depths {
+			if ip.Depth == depth {
+				out = appendDeployments(out, ip.Path.Deployments...)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func appendDeployments(list Deployments, deployments ...*Deployment) Deployments {
+	for _, d := range deployments {
+		if !list.Includes(d) {
+			list = append(list, d)
+		}
+	}
+	return list
+}
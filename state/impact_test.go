@@ -0,0 +1,97 @@
+package state_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/bosh"
+	"github.com/cloudfoundry-community/carousel/credhub"
+	. "github.com/cloudfoundry-community/carousel/state"
+)
+
+var _ = Describe("Impact", func() {
+	var state State
+
+	BeforeEach(func() {
+		f := newCertFactory()
+		t0 := time.Now().Add(-time.Hour)
+
+		root := f.certificate("/root", "root-v1", true, nil, t0)
+		inter := f.certificate("/intermediate", "intermediate-v1", true, root, t0.Add(time.Minute))
+		leaf := f.certificate("/leaf", "leaf-v1", false, inter, t0.Add(2*time.Minute))
+		// also reachable from the root directly through the ca bundle
+		leaf.Ca = append(leaf.Ca, root.Certificate)
+		otherCa := f.certificate("/other_ca", "other-ca-v1", true, nil, t0.Add(3*time.Minute))
+		other := f.certificate("/other", "other-v1", false, otherCa, t0.Add(4*time.Minute))
+		other.Ca = append(other.Ca, root.Certificate)
+
+		state = NewState()
+		Expect(state.Update(
+			[]*credhub.Credential{root, inter, leaf, otherCa, other},
+			[]*bosh.Variable{
+				{ID: "root-v1", Name: "/root", Deployment: "infra"},
+				{ID: "intermediate-v1", Name: "/intermediate", Deployment: "cf"},
+				{ID: "leaf-v1", Name: "/leaf", Deployment: "app"},
+				{ID: "other-v1", Name: "/other", Deployment: "other"},
+				{ID: "other-ca-v1", Name: "/other_ca", Deployment: "other"},
+			})).To(Succeed())
+	})
+
+	Context("of a root certificate authority", func() {
+		var impact Impact
+
+		BeforeEach(func() {
+			impact = pathNamed(state, "/root").Impact()
+		})
+
+		It("walks signed and referencing credentials", func() {
+			depths := make(map[string]int)
+			for _, ip := range impact.Paths {
+				depths[ip.Path.Name] = ip.Depth
+			}
+			Expect(depths).To(Equal(map[string]int{
+				"/root": 0, "/intermediate": 1, "/other": 1, "/leaf": 2,
+			}))
+			Expect(impact.Deployments.Names()).To(Equal([]string{"app", "cf", "infra", "other"}))
+		})
+
+		It("suggests a deploy round per level", func() {
+			Expect(impact.Rounds).To(HaveLen(4))
+			Expect(impact.Rounds[0].Deployments.Names()).To(Equal([]string{"cf", "infra", "other"}))
+			Expect(impact.Rounds[1].Deployments.Names()).To(Equal([]string{"app", "cf", "other"}))
+			Expect(impact.Rounds[2].Deployments.Names()).To(Equal([]string{"app"}))
+			Expect(impact.Rounds[3].Deployments.Names()).To(Equal([]string{"app", "cf", "infra", "other"}))
+		})
+	})
+
+	Context("of a certificate authority with levels without deployments", func() {
+		It("leaves out the empty deploy rounds", func() {
+			f := newCertFactory()
+			t0 := time.Now().Add(-time.Hour)
+			root := f.certificate("/root", "root-v1", true, nil, t0)
+			inter := f.certificate("/intermediate", "intermediate-v1", true, root, t0.Add(time.Minute))
+			leaf := f.certificate("/leaf", "leaf-v1", false, inter, t0.Add(2*time.Minute))
+
+			state = NewState()
+			Expect(state.Update(
+				[]*credhub.Credential{root, inter, leaf},
+				[]*bosh.Variable{{ID: "root-v1", Name: "/root", Deployment: "infra"}})).To(Succeed())
+
+			impact := pathNamed(state, "/root").Impact()
+			Expect(impact.Rounds).To(HaveLen(2))
+			Expect(impact.Rounds[0].Description).To(Equal("deploy the new transitional /root"))
+			Expect(impact.Rounds[1].Description).To(ContainSubstring("removing the transitional flag"))
+		})
+	})
+
+	Context("of a leaf", func() {
+		It("needs a single deploy round", func() {
+			impact := pathNamed(state, "/leaf").Impact()
+			Expect(impact.Paths).To(HaveLen(1))
+			Expect(impact.Rounds).To(HaveLen(1))
+			Expect(impact.Rounds[0].Deployments.Names()).To(Equal([]string{"app"}))
+		})
+	})
+})
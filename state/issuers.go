@@ -0,0 +1,199 @@
+package state
+
+import (
+	"crypto/sha256"
+	"runtime"
+	"sync"
+)
+
+// IssuerStatus records how the issuer of a certificate was established.
+type IssuerStatus string
+
+const (
+	// IssuerVerified: exactly one certificate authority path verifies the
+	// signature of the certificate (possibly several versions sharing a key).
+	IssuerVerified IssuerStatus = "verified"
+	// IssuerAmbiguous: certificate authorities on different paths verify the
+	// signature, e.g. when the issuer has been cross-signed.
+	IssuerAmbiguous IssuerStatus = "ambiguous"
+	// IssuerUnverified: none of the certificate authorities known to CredHub
+	// which may have signed the certificate verifies its signature.
+	IssuerUnverified IssuerStatus = "unverified"
+	// IssuerExternal: no certificate authority known to CredHub may have
+	// signed the certificate, it is signed outside of CredHub.
+	IssuerExternal IssuerStatus = "external"
+)
+
+// Flagged is true for certificates for which the issuer could not be
+// established with certainty. Actions depending on the issuer are skipped
+// for these.
+func (s IssuerStatus) Flagged() bool {
+	return s == IssuerAmbiguous || s == IssuerUnverified
+}
+
+// issuerCandidates returns the certificates which may have signed cert,
+// looked up by authority key ID and falling back on the issuer name for
+// certificates authorities without a subject key ID.
+func (s *snapshot) issuerCandidates(cert *Credential) Credentials {
+	candidates := make(Credentials, 0)
+	if len(cert.Certificate.AuthorityKeyId) != 0 {
+		candidates = append(candidates, s.getCredentialsBySubjectKeyId(cert.Certificate.AuthorityKeyId)...)
+	}
+	if len(candidates) == 0 {
+		candidates = append(candidates, s.subjects[string(cert.Certificate.RawIssuer)]...)
+	}
+
+	out := make(Credentials, 0, len(candidates))
+	for _, c := range candidates {
+		if c != cert {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// signaturePair identifies a certificate and a candidate issuer by the
+// digests of their raw certificates.
+type signaturePair struct {
+	cert, issuer [sha256.Size]byte
+}
+
+// linkIssuers verifies the signature of every certificate against its
+// candidate issuers and links it to the ones which verify. Signature checks
+// dominate the cost of an update, so results are carried over from the
+// previous snapshot and new checks are spread over all CPUs.
+func (s *snapshot) linkIssuers(certs Credentials, previous *snapshot) {
+	type check struct {
+		pair     signaturePair
+		issuer   *Credential
+		verified bool
+		cached   bool
+	}
+	type job struct {
+		cert   *Credential
+		checks []*check
+	}
+
+	digests := make(map[*Credential][sha256.Size]byte)
+	digest := func(c *Credential) [sha256.Size]byte {
+		if d, found := digests[c]; found {
+			return d
+		}
+		d := sha256.Sum256(c.Certificate.Raw)
+		digests[c] = d
+		return d
+	}
+
+	jobs := make([]*job, 0, len(certs))
+	for _, cert := range certs {
+		if cert.SelfSigned || cert.Certificate == nil {
+			continue
+		}
+		j := &job{cert: cert}
+		for _, ca := range s.issuerCandidates(cert) {
+			if ca.Certificate == nil {
+				continue
+			}
+			c := &check{pair: signaturePair{digest(cert), digest(ca)}, issuer: ca}
+			c.verified, c.cached = previous.signatures[c.pair]
+			j.checks = append(j.checks, c)
+		}
+		jobs = append(jobs, j)
+	}
+
+	work := make(chan *job)
+	var wg sync.WaitGroup
+	for t := 0; t < runtime.NumCPU(); t++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range work {
+				for _, c := range j.checks {
+					if !c.cached {
+						c.verified = j.cert.Certificate.CheckSignatureFrom(c.issuer.Certificate) == nil
+					}
+				}
+			}
+		}()
+	}
+	for _, j := range jobs {
+		work <- j
+	}
+	close(work)
+	wg.Wait()
+
+	for _, j := range jobs {
+		verified := make(Credentials, 0)
+		for _, c := range j.checks {
+			s.signatures[c.pair] = c.verified
+			if c.verified {
+				verified = append(verified, c.issuer)
+			}
+		}
+		s.linkIssuer(j.cert, len(j.checks), verified)
+	}
+}
+
+func (s *snapshot) linkIssuer(cert *Credential, candidates int, verified Credentials) {
+	cert.Issuers = verified
+	for _, ca := range verified {
+		ca.Signs = append(ca.Signs, cert)
+	}
+
+	switch {
+	case candidates == 0:
+		cert.IssuerStatus = IssuerExternal
+	case len(verified) == 0:
+		cert.IssuerStatus = IssuerUnverified
+	case !verified.samePath():
+		cert.IssuerStatus = IssuerAmbiguous
+	default:
+		cert.IssuerStatus = IssuerVerified
+		cert.SignedBy = preferredIssuer(verified)
+	}
+}
+
+// preferredIssuer picks the version CredHub signs with out of several
+// versions of one certificate authority sharing the same key: the newest
+// non transitional one.
+func preferredIssuer(versions Credentials) *Credential {
+	var out *Credential
+	for _, v := range versions {
+		switch {
+		case out == nil:
+			out = v
+		case out.Transitional != v.Transitional:
+			if out.Transitional {
+				out = v
+			}
+		case v.VersionCreatedAt.After(*out.VersionCreatedAt):
+			out = v
+		}
+	}
+	return out
+}
+
+// Chain returns the certificate followed by its verified issuers up to the
+// root. Complete is false when the chain ends in a certificate which is not
+// self-signed, because its issuer is ambiguous, unverified or unknown.
+func (c *Credential) Chain() (chain Credentials, complete bool) {
+	chain = Credentials{c}
+	for cur := c; ; cur = cur.SignedBy {
+		if cur.SignedBy == nil {
+			return chain, cur.SelfSigned
+		}
+		if chain.Includes(cur.SignedBy) {
+			return chain, false
+		}
+		chain = append(chain, cur.SignedBy)
+	}
+}
+
+func (creds Credentials) samePath() bool {
+	for _, c := range creds {
+		if c.Path != creds[0].Path {
+			return false
+		}
+	}
+	return true
+}
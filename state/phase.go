@@ -0,0 +1,119 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudfoundry-community/carousel/credhub"
+)
+
+//
+//go:generate go run github.com/alvaroloes/enumer -type=RotationPhase -json -transform=snake -trimprefix=Phase
+
+// RotationPhase is how far the rotation of a certificate authority path has
+// got, computed from the flags and deployments of its versions.
+type RotationPhase int
+
+const (
+	// PhaseIdle no rotation in progress
+	PhaseIdle RotationPhase = iota
+	// PhaseStaged a new version has been generated as transitional but
+	// is not deployed everywhere yet
+	PhaseStaged
+	// PhaseDeployed the new transitional version is deployed, the old
+	// version is still signing
+	PhaseDeployed
+	// PhaseSigningSwapped the new version is signing, certificates signed
+	// by the old version still have to be regenerated
+	PhaseSigningSwapped
+	// PhaseLeavesRegenerated all certificates have been regenerated but
+	// not all deployments use them yet
+	PhaseLeavesRegenerated
+	// PhaseOldTransitional nothing depends on the old version anymore,
+	// it only has to stop being transitional
+	PhaseOldTransitional
+	// PhaseCleanupPending unused old versions are waiting to be deleted
+	PhaseCleanupPending
+)
+
+// Phase computes the RotationPhase of p. Paths which do not hold a
+// certificate authority are always idle.
+func (p *Path) Phase() RotationPhase {
+	if len(p.Versions) == 0 {
+		return PhaseIdle
+	}
+
+	latest := p.Versions[0]
+	if latest.Type != credhub.Certificate || !latest.CertificateAuthority {
+		return PhaseIdle
+	}
+
+	if latest.Transitional && len(p.Versions) > 1 {
+		if !latest.Active() || len(latest.PendingDeploys()) != 0 {
+			return PhaseStaged
+		}
+		return PhaseDeployed
+	}
+
+	phase := PhaseIdle
+	for _, old := range p.Versions[1:] {
+		switch {
+		case old.Transitional:
+			next := PhaseOldTransitional
+			for _, cred := range old.Signs {
+				if cred.Latest {
+					next = PhaseSigningSwapped
+					break
+				}
+			}
+			if next == PhaseOldTransitional && old.stillIssuing() {
+				next = PhaseLeavesRegenerated
+			}
+			if phase == PhaseIdle || phase == PhaseCleanupPending || next < phase {
+				phase = next
+			}
+		case !old.Active() && phase == PhaseIdle:
+			phase = PhaseCleanupPending
+		}
+	}
+	return phase
+}
+
+// ValidatePhaseTransition returns an error when a path moved from one
+// rotation phase to another in a way a rotation never does. Phases may be
+// skipped as several actions can be performed between two refreshes, but
+// only a finished rotation may start over.
+func ValidatePhaseTransition(from, to RotationPhase) error {
+	switch {
+	case to >= from:
+		return nil
+	case to == PhaseIdle || to == PhaseStaged:
+		if from >= PhaseLeavesRegenerated {
+			return nil
+		}
+	}
+	return fmt.Errorf("illegal rotation phase transition from %s to %s", from, to)
+}
+
+// Phases returns the RotationPhase of every certificate authority path in
+// the snapshot keyed by path name.
+func (s *snapshot) Phases() map[string]RotationPhase {
+	out := make(map[string]RotationPhase)
+	s.eachPath(func(p *Path) {
+		if phase := p.Phase(); phase != PhaseIdle {
+			out[p.Name] = phase
+		}
+	})
+	return out
+}
+
+func (p *Path) MarshalJSON() ([]byte, error) {
+	type Alias Path
+	return json.Marshal(&struct {
+		*Alias
+		RotationPhase RotationPhase `json:"rotation_phase"`
+	}{
+		Alias:         (*Alias)(p),
+		RotationPhase: p.Phase(),
+	})
+}
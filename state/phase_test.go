@@ -0,0 +1,110 @@
+package state_test
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/credhub"
+	. "github.com/cloudfoundry-community/carousel/state"
+)
+
+var _ = Describe("RotationPhase", func() {
+	var (
+		state       State
+		ca1         *credhub.Credential
+		ca2         *credhub.Credential
+		leaf1       *credhub.Credential
+		leaf2       *credhub.Credential
+		credentials []*credhub.Credential
+		deployed    []*credhub.Credential
+	)
+
+	phase := func(name string) RotationPhase {
+		Expect(state.Update(credentials, variablesOf("app", deployed))).To(Succeed())
+		return pathNamed(state, name).Phase()
+	}
+
+	BeforeEach(func() {
+		f := newCertFactory()
+		t0 := time.Now().Add(-time.Hour)
+
+		state = NewState()
+		ca1 = f.certificate("/ca", "ca-v1", true, nil, t0)
+		leaf1 = f.certificate("/leaf", "leaf-v1", false, ca1, t0.Add(time.Minute))
+		ca2 = f.certificate("/ca", "ca-v2", true, nil, t0.Add(10*time.Minute))
+		leaf2 = f.certificate("/leaf", "leaf-v2", false, ca2, t0.Add(20*time.Minute))
+
+		credentials = []*credhub.Credential{ca1, leaf1}
+		deployed = []*credhub.Credential{ca1, leaf1}
+	})
+
+	It("follows a certificate authority through its rotation", func() {
+		Expect(phase("/ca")).To(Equal(PhaseIdle))
+		Expect(phase("/leaf")).To(Equal(PhaseIdle))
+
+		By("staging a new transitional version")
+		ca2.Transitional = true
+		leaf1.Ca = append(leaf1.Ca, ca2.Certificate)
+		credentials = append(credentials, ca2)
+		Expect(phase("/ca")).To(Equal(PhaseStaged))
+
+		By("deploying the new version")
+		deployed = []*credhub.Credential{ca2, leaf1}
+		Expect(phase("/ca")).To(Equal(PhaseDeployed))
+
+		By("swapping the signing version")
+		ca1.Transitional, ca2.Transitional = true, false
+		Expect(phase("/ca")).To(Equal(PhaseSigningSwapped))
+
+		By("regenerating the leaf")
+		credentials = append(credentials, leaf2)
+		Expect(phase("/ca")).To(Equal(PhaseLeavesRegenerated))
+
+		By("deploying the regenerated leaf")
+		deployed = []*credhub.Credential{ca2, leaf2}
+		Expect(phase("/ca")).To(Equal(PhaseOldTransitional))
+
+		By("removing the transitional flag of the old version")
+		ca1.Transitional = false
+		Expect(phase("/ca")).To(Equal(PhaseCleanupPending))
+
+		By("deleting the old version")
+		credentials = []*credhub.Credential{ca2, leaf1, leaf2}
+		Expect(phase("/ca")).To(Equal(PhaseIdle))
+	})
+
+	It("is part of the path json", func() {
+		ca2.Transitional = true
+		credentials = append(credentials, ca2)
+		Expect(phase("/ca")).To(Equal(PhaseStaged))
+
+		cred, _ := state.Credentials(NameFilter("/ca")).Find(LatestFilter())
+		out, err := json.Marshal(cred.Path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(ContainSubstring(`"rotation_phase":"staged"`))
+		Expect(state.Phases()).To(Equal(map[string]RotationPhase{"/ca": PhaseStaged}))
+	})
+
+	DescribeTable("ValidatePhaseTransition",
+		func(from, to RotationPhase, legal bool) {
+			err := ValidatePhaseTransition(from, to)
+			if legal {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(err).To(HaveOccurred())
+			}
+		},
+		Entry("staying in a phase", PhaseDeployed, PhaseDeployed, true),
+		Entry("starting a rotation", PhaseIdle, PhaseStaged, true),
+		Entry("moving to the next phase", PhaseStaged, PhaseDeployed, true),
+		Entry("skipping phases", PhaseSigningSwapped, PhaseOldTransitional, true),
+		Entry("finishing a rotation", PhaseOldTransitional, PhaseIdle, true),
+		Entry("starting over after cleanup", PhaseCleanupPending, PhaseStaged, true),
+		Entry("aborting a staged rotation", PhaseStaged, PhaseIdle, false),
+		Entry("swapping back", PhaseSigningSwapped, PhaseDeployed, false),
+	)
+})
@@ -0,0 +1,98 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Plan is a set of actions, e.g. approved by a change process, together with
+// a fingerprint of the state they were planned for. A plan is only carried
+// out while the state still matches the fingerprint.
+type Plan struct {
+	CreatedAt   time.Time       `json:"created_at"`
+	Fingerprint string          `json:"fingerprint"`
+	Actions     []PlannedAction `json:"actions"`
+}
+
+type PlannedAction struct {
+	Action Action `json:"action"`
+	Name   string `json:"name"`
+	ID     string `json:"id"`
+}
+
+// NewPlan plans the given action for each of credentials.
+func NewPlan(credentials Credentials, action func(*Credential) Action, now time.Time) Plan {
+	out := Plan{CreatedAt: now, Actions: make([]PlannedAction, 0, len(credentials))}
+	for _, cred := range credentials {
+		out.Actions = append(out.Actions, PlannedAction{Action: action(cred), Name: cred.Name, ID: cred.ID})
+	}
+	out.Fingerprint = out.fingerprint(credentials)
+	return out
+}
+
+// Resolve returns the credentials of the planned actions in order, or an
+// error when any of them is gone or the state they are part of changed.
+func (p Plan) Resolve(credentials Credentials) (Credentials, error) {
+	byID := make(map[string]*Credential, len(credentials))
+	for _, cred := range credentials {
+		byID[cred.ID] = cred
+	}
+
+	out := make(Credentials, 0, len(p.Actions))
+	for _, a := range p.Actions {
+		cred, found := byID[a.ID]
+		if !found || cred.Name != a.Name {
+			return nil, fmt.Errorf("planned credential no longer exists: %s@%s", a.Name, a.ID)
+		}
+		out = append(out, cred)
+	}
+
+	if fingerprint := p.fingerprint(out); fingerprint != p.Fingerprint {
+		return nil, fmt.Errorf("state changed since the plan was made (fingerprint %s, planned %s)",
+			fingerprint, p.Fingerprint)
+	}
+	return out, nil
+}
+
+// fingerprint hashes the planned actions together with the ids, flags,
+// deployments and issuers of all versions of the paths involved.
+func (p Plan) fingerprint(credentials Credentials) string {
+	lines := make([]string, 0)
+	for _, a := range p.Actions {
+		lines = append(lines, fmt.Sprintf("action %s %s@%s", a.Action, a.Name, a.ID))
+	}
+
+	seen := make(map[*Path]bool)
+	for _, cred := range credentials {
+		if seen[cred.Path] {
+			continue
+		}
+		seen[cred.Path] = true
+		lines = append(lines, fmt.Sprintf("path %s deployments=%s",
+			cred.Path.Name, sortedNames(cred.Path.Deployments)))
+		for _, v := range cred.Path.Versions {
+			signing := v.Signing != nil && *v.Signing
+			signedBy := ""
+			if v.SignedBy != nil {
+				signedBy = v.SignedBy.PathVersion()
+			}
+			lines = append(lines, fmt.Sprintf(
+				"version %s@%s latest=%t transitional=%t signing=%t deployed_to=%s signed_by=%s",
+				v.Name, v.ID, v.Latest, v.Transitional, signing, sortedNames(v.Deployments), signedBy))
+		}
+	}
+	sort.Strings(lines)
+
+	hash := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(hash[:])
+}
+
+func sortedNames(deployments Deployments) string {
+	names := deployments.Names()
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
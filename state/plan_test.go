@@ -0,0 +1,107 @@
+package state_test
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/bosh"
+	"github.com/cloudfoundry-community/carousel/credhub"
+	. "github.com/cloudfoundry-community/carousel/state"
+)
+
+var _ = Describe("Plan", func() {
+	var (
+		state       State
+		t0          time.Time
+		credentials []*credhub.Credential
+		variables   []*bosh.Variable
+		plan        Plan
+	)
+
+	regenerate := func(*Credential) Action { return Regenerate }
+
+	BeforeEach(func() {
+		t0 = time.Now().Add(-time.Hour)
+		credentials = []*credhub.Credential{password("/a", "a-v1", t0), password("/b", "b-v1", t0)}
+		variables = []*bosh.Variable{
+			{ID: "a-v1", Name: "/a", Deployment: "cf"},
+			{ID: "b-v1", Name: "/b", Deployment: "cf"},
+		}
+
+		state = NewState()
+		Expect(state.Update(credentials, variables)).To(Succeed())
+		plan = NewPlan(state.Credentials(NameFilter("/a")), regenerate, t0)
+
+		// plans are stored as json
+		data, err := json.Marshal(plan)
+		Expect(err).ToNot(HaveOccurred())
+		plan = Plan{}
+		Expect(json.Unmarshal(data, &plan)).To(Succeed())
+	})
+
+	It("plans the actions", func() {
+		Expect(plan.Actions).To(Equal([]PlannedAction{{Action: Regenerate, Name: "/a", ID: "a-v1"}}))
+		Expect(plan.Fingerprint).To(HaveLen(64))
+	})
+
+	It("resolves the credentials while the state matches", func() {
+		Expect(state.Update(credentials, variables)).To(Succeed())
+		resolved, err := plan.Resolve(state.Credentials())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resolved).To(HaveLen(1))
+		Expect(resolved[0].PathVersion()).To(Equal("/a@a-v1"))
+	})
+
+	It("ignores changes to other paths", func() {
+		credentials = append(credentials, password("/b", "b-v2", t0.Add(time.Minute)))
+		Expect(state.Update(credentials, variables)).To(Succeed())
+		_, err := plan.Resolve(state.Credentials())
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("refuses when a version was added", func() {
+		credentials = append(credentials, password("/a", "a-v2", t0.Add(time.Minute)))
+		Expect(state.Update(credentials, variables)).To(Succeed())
+		_, err := plan.Resolve(state.Credentials())
+		Expect(err).To(MatchError(ContainSubstring("state changed since the plan was made")))
+	})
+
+	It("refuses when the deployments changed", func() {
+		variables = append(variables, &bosh.Variable{ID: "a-v1", Name: "/a", Deployment: "app"})
+		Expect(state.Update(credentials, variables)).To(Succeed())
+		_, err := plan.Resolve(state.Credentials())
+		Expect(err).To(MatchError(ContainSubstring("state changed since the plan was made")))
+	})
+
+	It("refuses when the issuer changed", func() {
+		f := newCertFactory()
+		key := newKey()
+		oldCa := f.certificateWithKey(key, "/ca", "ca-v1", true, nil, t0.Add(-time.Hour))
+		newCa := f.certificateWithKey(key, "/ca", "ca-v2", true, nil, t0)
+		newCa.Transitional = true
+		leaf := f.certificate("/leaf", "leaf-v1", false, oldCa, t0)
+		credentials = append(credentials, oldCa, newCa, leaf)
+		Expect(state.Update(credentials, variables)).To(Succeed())
+		plan = NewPlan(state.Credentials(NameFilter("/leaf")), regenerate, t0)
+
+		newCa.Transitional = false
+		Expect(state.Update(credentials, variables)).To(Succeed())
+		_, err := plan.Resolve(state.Credentials())
+		Expect(err).To(MatchError(ContainSubstring("state changed since the plan was made")))
+	})
+
+	It("refuses when the actions were changed", func() {
+		plan.Actions[0].Action = CleanUp
+		_, err := plan.Resolve(state.Credentials())
+		Expect(err).To(MatchError(ContainSubstring("state changed since the plan was made")))
+	})
+
+	It("refuses when a planned credential is gone", func() {
+		Expect(state.Update(credentials[1:], variables[1:])).To(Succeed())
+		_, err := plan.Resolve(state.Credentials())
+		Expect(err).To(MatchError("planned credential no longer exists: /a@a-v1"))
+	})
+})
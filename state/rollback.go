@@ -0,0 +1,63 @@
+package state
+
+import (
+	"fmt"
+)
+
+// Rollback describes restoring the value of a previous version of a path as
+// its new latest version.
+type Rollback struct {
+	Path *Path
+	// Target is the version whose value is restored
+	Target *Credential
+	// Current is the latest version which is rolled back
+	Current *Credential
+	// UpdateTransitional is true for certificate authorities, which after
+	// restoring Target have to mark Transitional as transitional, or remove
+	// the transitional flag when Transitional is nil
+	UpdateTransitional bool
+	Transitional       *Credential
+	// Deployments need a redeploy to use the restored value
+	Deployments Deployments
+}
+
+// Rollback plans restoring the value of the version with id, or of the
+// version before the latest one when id is empty.
+//
+// A certificate authority keeps trusting the rolled back version by marking
+// it transitional as long as it signed credentials which are still latest
+// or deployed, so these keep working until they are regenerated.
+// Otherwise the transitional flag is removed, which ends a rotation started
+// by regenerating the certificate authority.
+func (p *Path) Rollback(id string) (*Rollback, error) {
+	if len(p.Versions) < 2 {
+		return nil, fmt.Errorf("%s has no previous version to roll back to", p.Name)
+	}
+
+	current := p.Versions[0]
+	target := p.Versions[1]
+	if id != "" {
+		var found bool
+		if target, found = p.Versions.Find(func(c *Credential) bool { return c.ID == id }); !found {
+			return nil, fmt.Errorf("version not found: %s@%s", p.Name, id)
+		}
+	}
+	if target == current {
+		return nil, fmt.Errorf("%s is already the latest version", target.PathVersion())
+	}
+
+	out := &Rollback{
+		Path:        p,
+		Target:      target,
+		Current:     current,
+		Deployments: p.Impact().Deployments,
+	}
+
+	if current.CertificateAuthority {
+		out.UpdateTransitional = true
+		if current.stillIssuing() {
+			out.Transitional = current
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,103 @@
+package state_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/bosh"
+	"github.com/cloudfoundry-community/carousel/credhub"
+	. "github.com/cloudfoundry-community/carousel/state"
+)
+
+var _ = Describe("Rollback", func() {
+	var (
+		state State
+		f     *certFactory
+		t0    time.Time
+	)
+
+	BeforeEach(func() {
+		f = newCertFactory()
+		t0 = time.Now().Add(-time.Hour)
+		state = NewState()
+	})
+
+	Context("of a certificate authority which has just been regenerated", func() {
+		BeforeEach(func() {
+			ca1 := f.certificate("/ca", "ca-v1", true, nil, t0)
+			ca2 := f.certificate("/ca", "ca-v2", true, nil, t0.Add(2*time.Minute))
+			ca2.Transitional = true
+			leaf := f.certificate("/leaf", "leaf-v1", false, ca1, t0.Add(time.Minute))
+
+			Expect(state.Update(
+				[]*credhub.Credential{ca1, ca2, leaf},
+				[]*bosh.Variable{
+					{ID: "ca-v2", Name: "/ca", Deployment: "cf"},
+					{ID: "leaf-v1", Name: "/leaf", Deployment: "app"},
+				})).To(Succeed())
+		})
+
+		It("restores the previous version and removes the transitional flag", func() {
+			rollback, err := pathNamed(state, "/ca").Rollback("")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rollback.Target.ID).To(Equal("ca-v1"))
+			Expect(rollback.Current.ID).To(Equal("ca-v2"))
+			Expect(rollback.UpdateTransitional).To(BeTrue())
+			Expect(rollback.Transitional).To(BeNil())
+			Expect(rollback.Deployments.Names()).To(ConsistOf("cf", "app"))
+		})
+	})
+
+	Context("of a certificate authority which already signed credentials", func() {
+		BeforeEach(func() {
+			ca1 := f.certificate("/ca", "ca-v1", true, nil, t0)
+			ca1.Transitional = true
+			ca2 := f.certificate("/ca", "ca-v2", true, nil, t0.Add(time.Minute))
+			leaf := f.certificate("/leaf", "leaf-v2", false, ca2, t0.Add(2*time.Minute))
+
+			Expect(state.Update(
+				[]*credhub.Credential{ca1, ca2, leaf},
+				[]*bosh.Variable{
+					{ID: "ca-v2", Name: "/ca", Deployment: "cf"},
+					{ID: "leaf-v2", Name: "/leaf", Deployment: "app"},
+				})).To(Succeed())
+		})
+
+		It("keeps trusting the rolled back version", func() {
+			rollback, err := pathNamed(state, "/ca").Rollback("ca-v1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rollback.Target.ID).To(Equal("ca-v1"))
+			Expect(rollback.UpdateTransitional).To(BeTrue())
+			Expect(rollback.Transitional).ToNot(BeNil())
+			Expect(rollback.Transitional.ID).To(Equal("ca-v2"))
+		})
+	})
+
+	Context("of a password", func() {
+		BeforeEach(func() {
+			Expect(state.Update(
+				[]*credhub.Credential{password("/pw", "pw-v1", t0), password("/pw", "pw-v2", t0.Add(time.Minute))},
+				[]*bosh.Variable{{ID: "pw-v2", Name: "/pw", Deployment: "cf"}})).To(Succeed())
+		})
+
+		It("does not update transitional flags", func() {
+			rollback, err := pathNamed(state, "/pw").Rollback("")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rollback.Target.ID).To(Equal("pw-v1"))
+			Expect(rollback.UpdateTransitional).To(BeFalse())
+			Expect(rollback.Deployments.Names()).To(Equal([]string{"cf"}))
+		})
+
+		It("fails for the latest version", func() {
+			_, err := pathNamed(state, "/pw").Rollback("pw-v2")
+			Expect(err).To(MatchError("/pw@pw-v2 is already the latest version"))
+		})
+
+		It("fails for unknown versions", func() {
+			_, err := pathNamed(state, "/pw").Rollback("pw-v3")
+			Expect(err).To(MatchError("version not found: /pw@pw-v3"))
+		})
+	})
+})
@@ -0,0 +1,257 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-community/carousel/bosh"
+	"github.com/cloudfoundry-community/carousel/credhub"
+)
+
+// Rule decides the next action for the credentials it applies to. Rules are
+// evaluated in order and the first one which applies decides the action.
+type Rule interface {
+	Name() string
+	Evaluate(cred *Credential, r RegenerationCriteria) (action Action, applies bool)
+}
+
+type RuleFunc func(cred *Credential, r RegenerationCriteria) (Action, bool)
+
+type Rules []Rule
+
+func NewRule(name string, fn RuleFunc) Rule {
+	return &rule{name: name, fn: fn}
+}
+
+type rule struct {
+	name   string
+	fn     RuleFunc
+	values ValuesFunc
+}
+
+func (r *rule) Name() string {
+	return r.name
+}
+
+func (r *rule) Evaluate(cred *Credential, criteria RegenerationCriteria) (Action, bool) {
+	return r.fn(cred, criteria)
+}
+
+// NextAction returns the action of the first rule which applies to cred.
+func (rules Rules) NextAction(cred *Credential, r RegenerationCriteria) Action {
+	return rules.Decide(cred, r).Action
+}
+
+func (rules Rules) Names() []string {
+	out := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		out = append(out, rule.Name())
+	}
+	return out
+}
+
+func (rules Rules) index(name string) int {
+	for i, rule := range rules {
+		if rule.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Insert returns a copy of rules with rule inserted before the rule named
+// before, or in front of all rules when before is empty.
+func (rules Rules) Insert(before string, rule Rule) (Rules, error) {
+	i := 0
+	if before != "" {
+		if i = rules.index(before); i == -1 {
+			return nil, fmt.Errorf("rule not found: %s", before)
+		}
+	}
+	out := make(Rules, 0, len(rules)+1)
+	out = append(out, rules[:i]...)
+	out = append(out, rule)
+	return append(out, rules[i:]...), nil
+}
+
+// InsertAfter returns a copy of rules with rule inserted after the rule
+// named after, or in front of all rules when there is no such rule.
+func (rules Rules) InsertAfter(after string, rule Rule) Rules {
+	i := rules.index(after) + 1
+	out := make(Rules, 0, len(rules)+1)
+	out = append(out, rules[:i]...)
+	out = append(out, rule)
+	return append(out, rules[i:]...)
+}
+
+// RuleSet returns the built-in rules in order, with the default ones and
+// the optional ones given in enable, minus the ones given in disable.
+func RuleSet(enable, disable []string) (Rules, error) {
+	for _, name := range append(append([]string{}, enable...), disable...) {
+		if builtinRules.index(name) == -1 {
+			return nil, fmt.Errorf("unknown rule: %s (known rules: %v)", name, builtinRules.Names())
+		}
+	}
+
+	out := make(Rules, 0, len(builtinRules))
+	for _, rule := range builtinRules {
+		enabled := !optionalRules[rule.Name()] || includesString(enable, rule.Name())
+		if enabled && !includesString(disable, rule.Name()) {
+			out = append(out, rule)
+		}
+	}
+	return out, nil
+}
+
+// DefaultRules returns the rules carousel uses when not configured otherwise.
+func DefaultRules() Rules {
+	rules, _ := RuleSet(nil, nil)
+	return rules
+}
+
+// BuiltinRules returns all built-in rules in order, including the optional
+// ones which are disabled by default.
+func BuiltinRules() Rules {
+	return append(Rules{}, builtinRules...)
+}
+
+// OptionalRule reports whether a built-in rule is disabled by default.
+func OptionalRule(name string) bool {
+	return optionalRules[name]
+}
+
+var optionalRules = map[string]bool{
+	"deploy-before-regenerate": true,
+}
+
+var builtinRules = Rules{
+	NewExplainedRule("unmanaged-type", func(cred *Credential, r RegenerationCriteria) (Action, bool) {
+		return None, cred.Type == credhub.JSON || cred.Type == credhub.Value
+	}, func(cred *Credential, r RegenerationCriteria) []Value {
+		return []Value{{Name: "type", Value: cred.Type.String()}}
+	}),
+
+	NewExplainedRule("update-mode", func(cred *Credential, r RegenerationCriteria) (Action, bool) {
+		return NoOverwrite, !r.IgnoreUpdateMode && cred.Path.VariableDefinition != nil &&
+			cred.Path.VariableDefinition.UpdateMode == bosh.NoOverwrite
+	}, func(cred *Credential, r RegenerationCriteria) []Value {
+		return []Value{{Name: "update_mode", Value: string(cred.Path.VariableDefinition.UpdateMode)}}
+	}),
+
+	NewExplainedRule("flagged-issuer", func(cred *Credential, r RegenerationCriteria) (Action, bool) {
+		if !cred.IssuerStatus.Flagged() {
+			return None, false
+		}
+		if cred.Latest && len(cred.PendingDeploys()) != 0 {
+			return BoshDeploy, true
+		}
+		return None, true
+	}, func(cred *Credential, r RegenerationCriteria) []Value {
+		return []Value{
+			{Name: "issuer_status", Value: string(cred.IssuerStatus)},
+			deploymentsValue("pending_deploys", cred.PendingDeploys()),
+		}
+	}),
+
+	NewExplainedRule("mark-transitional", func(cred *Credential, r RegenerationCriteria) (Action, bool) {
+		if cred.Signing == nil || !*cred.Signing {
+			return None, false
+		}
+		latest, found := cred.Path.Versions.Find(LatestFilter())
+		return MarkTransitional, found && latest.Transitional && latest.Active() &&
+			len(latest.PendingDeploys()) == 0
+	}, func(cred *Credential, r RegenerationCriteria) []Value {
+		latest, _ := cred.Path.Versions.Find(LatestFilter())
+		return []Value{
+			credentialValue("transitional_latest", latest),
+			deploymentsValue("deployed_to", latest.Deployments),
+		}
+	}),
+
+	// Finish deploying the latest version before regenerating it again,
+	// e.g. for deployments which are in the middle of a deploy.
+	NewExplainedRule("deploy-before-regenerate", func(cred *Credential, r RegenerationCriteria) (Action, bool) {
+		return BoshDeploy, cred.pendingDeploy()
+	}, pendingDeployValues),
+
+	// Wait for the certificate authorities above to be rotated first,
+	// in a root > intermediate > leaf hierarchy this moves top down.
+	NewExplainedRule("superseded-issuer", func(cred *Credential, r RegenerationCriteria) (Action, bool) {
+		return Regenerate, cred.Latest && !cred.awaitingIssuerRotation() && cred.supersededIssuer()
+	}, func(cred *Credential, r RegenerationCriteria) []Value {
+		latestCa, _ := cred.SignedBy.Path.Versions.Find(LatestFilter())
+		return []Value{credentialValue("signed_by", cred.SignedBy), credentialValue("latest_ca", latestCa)}
+	}),
+
+	NewExplainedRule("expiry", func(cred *Credential, r RegenerationCriteria) (Action, bool) {
+		if !cred.Latest || cred.ExpiryDate == nil || !cred.ExpiryDate.Before(r.ExpiresBefore) ||
+			cred.awaitingIssuerRotation() {
+			return None, false
+		}
+		// an expiring ca will be regenerated first
+		if cred.SignedBy != nil && cred.SignedBy.ExpiryDate.Before(r.ExpiresBefore) {
+			return None, true
+		}
+		return Regenerate, true
+	}, func(cred *Credential, r RegenerationCriteria) []Value {
+		values := []Value{
+			timeValue("expiry_date", cred.ExpiryDate),
+			timeValue("expires_before", &r.ExpiresBefore),
+		}
+		if cred.SignedBy != nil && cred.SignedBy.ExpiryDate.Before(r.ExpiresBefore) {
+			values = append(values, credentialValue("expiring_ca", cred.SignedBy),
+				timeValue("ca_expiry_date", cred.SignedBy.ExpiryDate))
+		}
+		return values
+	}),
+
+	NewExplainedRule("age", func(cred *Credential, r RegenerationCriteria) (Action, bool) {
+		return Regenerate, cred.Latest && cred.VersionCreatedAt.Before(r.OlderThan) &&
+			!cred.awaitingIssuerRotation()
+	}, func(cred *Credential, r RegenerationCriteria) []Value {
+		return []Value{
+			timeValue("version_created_at", cred.VersionCreatedAt),
+			timeValue("older_than", &r.OlderThan),
+		}
+	}),
+
+	NewExplainedRule("pending-deploy", func(cred *Credential, r RegenerationCriteria) (Action, bool) {
+		return BoshDeploy, cred.pendingDeploy()
+	}, pendingDeployValues),
+
+	NewExplainedRule("unmark-transitional", func(cred *Credential, r RegenerationCriteria) (Action, bool) {
+		if !cred.Transitional || cred.Latest {
+			return None, false
+		}
+		signing, found := cred.Path.Versions.Find(SigningFilter())
+		return UnMarkTransitional, found && len(signing.PendingDeploys()) == 0 && !cred.stillIssuing()
+	}, func(cred *Credential, r RegenerationCriteria) []Value {
+		signing, _ := cred.Path.Versions.Find(SigningFilter())
+		return []Value{
+			credentialValue("signing", signing),
+			deploymentsValue("signing_deployed_to", signing.Deployments),
+		}
+	}),
+
+	NewExplainedRule("cleanup", func(cred *Credential, r RegenerationCriteria) (Action, bool) {
+		return CleanUp, !cred.Active() && cred.Type == credhub.Certificate
+	}, func(cred *Credential, r RegenerationCriteria) []Value {
+		return []Value{deploymentsValue("deployed_to", cred.Deployments)}
+	}),
+}
+
+func pendingDeployValues(cred *Credential, r RegenerationCriteria) []Value {
+	return []Value{
+		deploymentsValue("deployed_to", cred.Deployments),
+		deploymentsValue("pending_deploys", cred.PendingDeploys()),
+	}
+}
+
+// pendingDeploy is true for the latest version of a credential when not all
+// deployments using its path use it yet. Self-signed certificates which are
+// not referenced by any other certificate are not deployed on their own.
+func (cred *Credential) pendingDeploy() bool {
+	return cred.Latest && len(cred.PendingDeploys()) != 0 &&
+		!(cred.Type == credhub.Certificate &&
+			cred.SignedBy == nil &&
+			len(cred.ReferencedBy) == 0)
+}
@@ -0,0 +1,143 @@
+package state_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/credhub"
+	. "github.com/cloudfoundry-community/carousel/state"
+)
+
+var _ = Describe("Rules", func() {
+	Describe("RuleSet", func() {
+		It("leaves out optional rules by default", func() {
+			Expect(DefaultRules().Names()).ToNot(ContainElement("deploy-before-regenerate"))
+			Expect(DefaultRules().Names()).To(HaveLen(len(BuiltinRules()) - 1))
+		})
+
+		It("enables optional rules in their place", func() {
+			rules, err := RuleSet([]string{"deploy-before-regenerate"}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rules.Names()).To(Equal(BuiltinRules().Names()))
+		})
+
+		It("disables rules", func() {
+			rules, err := RuleSet(nil, []string{"age", "cleanup"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rules.Names()).ToNot(ContainElement("age"))
+			Expect(rules.Names()).ToNot(ContainElement("cleanup"))
+		})
+
+		It("rejects unknown rules", func() {
+			_, err := RuleSet(nil, []string{"colour"})
+			Expect(err).To(MatchError(ContainSubstring("unknown rule: colour")))
+		})
+	})
+
+	Describe("Insert", func() {
+		never := NewRule("never", func(*Credential, RegenerationCriteria) (Action, bool) {
+			return None, true
+		})
+
+		It("inserts in front by default", func() {
+			rules, err := DefaultRules().Insert("", never)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rules.Names()[0]).To(Equal("never"))
+		})
+
+		It("inserts before a named rule", func() {
+			rules, err := DefaultRules().Insert("cleanup", never)
+			Expect(err).ToNot(HaveOccurred())
+			names := rules.Names()
+			Expect(names[len(names)-2:]).To(Equal([]string{"never", "cleanup"}))
+		})
+
+		It("fails for unknown rules", func() {
+			_, err := DefaultRules().Insert("colour", never)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("NextAction", func() {
+		var (
+			credential *Credential
+			criteria   RegenerationCriteria
+		)
+
+		BeforeEach(func() {
+			createdAt := time.Now().Add(-2 * time.Hour)
+			credential = &Credential{
+				Credential: &credhub.Credential{
+					Type:             credhub.Password,
+					VersionCreatedAt: &createdAt,
+				},
+				Latest: true,
+				Path:   &Path{Deployments: Deployments{{Name: "cf"}}},
+			}
+			credential.Path.Versions = Credentials{credential}
+			criteria = RegenerationCriteria{OlderThan: time.Now().Add(-time.Hour)}
+		})
+
+		It("uses the default rules", func() {
+			Expect(credential.NextAction(criteria)).To(Equal(Regenerate))
+		})
+
+		It("uses the rules of the criteria", func() {
+			var err error
+			criteria.Rules, err = RuleSet([]string{"deploy-before-regenerate"}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(credential.NextAction(criteria)).To(Equal(BoshDeploy))
+		})
+
+		It("falls back to the default rules when empty", func() {
+			criteria.Rules = Rules{}
+			Expect(credential.NextAction(criteria)).To(Equal(Regenerate))
+		})
+
+		It("returns None when no rule applies", func() {
+			criteria.Rules = Rules{NewRule("nothing", func(*Credential, RegenerationCriteria) (Action, bool) {
+				return Regenerate, false
+			})}
+			Expect(credential.NextAction(criteria)).To(Equal(None))
+		})
+	})
+
+	Describe("flagged-issuer", func() {
+		var (
+			certificate *Credential
+			criteria    RegenerationCriteria
+		)
+
+		BeforeEach(func() {
+			expiry := time.Now().Add(-time.Hour)
+			createdAt := time.Now().Add(-365 * 24 * time.Hour)
+			certificate = &Credential{
+				Credential: &credhub.Credential{
+					Type:             credhub.Certificate,
+					ExpiryDate:       &expiry,
+					VersionCreatedAt: &createdAt,
+				},
+				IssuerStatus: IssuerExternal,
+				Latest:       true,
+				Path:         &Path{},
+			}
+			certificate.Path.Versions = Credentials{certificate}
+			criteria = RegenerationCriteria{ExpiresBefore: time.Now()}
+		})
+
+		It("regenerates an expired certificate signed outside of CredHub", func() {
+			decision := certificate.Decide(criteria)
+			Expect(decision.Rule).To(Equal("expiry"))
+			Expect(decision.Action).To(Equal(Regenerate))
+		})
+
+		It("skips an expired certificate when its issuer does not verify", func() {
+			certificate.IssuerStatus = IssuerUnverified
+			decision := certificate.Decide(criteria)
+			Expect(decision.Rule).To(Equal("flagged-issuer"))
+			Expect(decision.Action).To(Equal(None))
+		})
+	})
+})
@@ -1,33 +1,192 @@
 package state
 
 import (
-	"github.com/emirpasic/gods/maps/treebidimap"
-	"github.com/emirpasic/gods/utils"
+	"sync"
+	"time"
+
 	"github.com/cloudfoundry-community/carousel/bosh"
 	"github.com/cloudfoundry-community/carousel/credhub"
 )
 
+// Snapshot is an immutable view of the credential graph as it was built by
+// a single call to State.Update.
+type Snapshot interface {
+	Credentials(...Filter) Credentials
+	Deployments() Deployments
+	Phases() map[string]RotationPhase
+	Skipped() []SkippedVersion
+	UpdatedAt() time.Time
+}
+
+// State holds the latest Snapshot. It is safe for concurrent use: Update
+// builds a new graph next to the current one and swaps it in once complete,
+// so readers never observe a partially built graph.
 type State interface {
+	Snapshot
 	Update([]*credhub.Credential, []*bosh.Variable) error
-	Credentials(...Filter) Credentials
+	Snapshot() Snapshot
+	Subscribe() (<-chan Event, func())
+}
+
+// Event is emitted to subscribers after every call to State.Update.
+// When the update failed Err is set and Current equals Previous.
+type Event struct {
+	Previous Snapshot
+	Current  Snapshot
+	Err      error
 }
 
 func NewState() State {
 	return &state{
-		deployments: treebidimap.NewWith(utils.StringComparator, deploymentComparator),
-		paths:       treebidimap.NewWith(utils.StringComparator, pathComparator),
-		credentials: treebidimap.NewWith(utils.StringComparator, credentialComparator),
+		current:     newSnapshot(),
+		subscribers: make(map[chan Event]struct{}),
 	}
 }
 
 type state struct {
-	deployments *treebidimap.Map
-	paths       *treebidimap.Map
-	credentials *treebidimap.Map
+	updating    sync.Mutex
+	lock        sync.RWMutex
+	current     *snapshot
+	subscribers map[chan Event]struct{}
+}
+
+// snapshot stores the credential graph in plain maps, indexed by the keys
+// used while linking, next to sorted slices for deterministic iteration.
+type snapshot struct {
+	updatedAt time.Time
+
+	deployments  map[string]*Deployment // by name
+	paths        map[string]*Path       // by name
+	credentials  map[string]*Credential // by ID
+	subjectKeys  map[string]Credentials // by certificate subject key ID
+	subjects     map[string]Credentials // by certificate raw subject
+	certificates map[string]*Credential // by raw certificate
+	signatures   map[signaturePair]bool // verified signatures
+	skipped      []SkippedVersion       // malformed versions left out
+
+	sortedDeployments Deployments // by name
+	sortedPaths       []*Path     // by name
+	sortedCredentials Credentials // by ID
+}
+
+func newSnapshot() *snapshot {
+	return &snapshot{
+		deployments:       make(map[string]*Deployment),
+		paths:             make(map[string]*Path),
+		credentials:       make(map[string]*Credential),
+		subjectKeys:       make(map[string]Credentials),
+		subjects:          make(map[string]Credentials),
+		certificates:      make(map[string]*Credential),
+		signatures:        make(map[signaturePair]bool),
+		skipped:           make([]SkippedVersion, 0),
+		sortedDeployments: make(Deployments, 0),
+		sortedPaths:       make([]*Path, 0),
+		sortedCredentials: make(Credentials, 0),
+	}
+}
+
+func (s *state) Update(credentials []*credhub.Credential, variables []*bosh.Variable) error {
+	s.updating.Lock()
+	defer s.updating.Unlock()
+
+	previous := s.snapshot()
+	next := newSnapshot()
+	err := next.update(credentials, variables, previous)
+	if err != nil {
+		s.publish(Event{Previous: previous, Current: previous, Err: err})
+		return err
+	}
+	next.updatedAt = time.Now()
+
+	s.lock.Lock()
+	s.current = next
+	s.lock.Unlock()
+
+	s.publish(Event{Previous: previous, Current: next})
+	return nil
+}
+
+func (s *state) Credentials(filters ...Filter) Credentials {
+	return s.snapshot().Credentials(filters...)
+}
+
+func (s *state) Deployments() Deployments {
+	return s.snapshot().Deployments()
+}
+
+func (s *state) Phases() map[string]RotationPhase {
+	return s.snapshot().Phases()
+}
+
+func (s *state) Skipped() []SkippedVersion {
+	return s.snapshot().Skipped()
+}
+
+func (s *state) UpdatedAt() time.Time {
+	return s.snapshot().UpdatedAt()
+}
+
+func (s *state) Snapshot() Snapshot {
+	return s.snapshot()
+}
+
+func (s *state) snapshot() *snapshot {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.current
+}
+
+// Subscribe returns a channel receiving an Event for every refresh and a
+// function to cancel the subscription. Slow subscribers only miss
+// intermediate events, the latest event is always delivered.
+func (s *state) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 1)
+
+	s.lock.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.lock.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.lock.Lock()
+			delete(s.subscribers, ch)
+			s.lock.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (s *state) publish(e Event) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- e:
+		default:
+			// drop the stale event in favour of the latest one
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- e
+		}
+	}
+}
+
+func (s *snapshot) UpdatedAt() time.Time {
+	return s.updatedAt
+}
+
+// Skipped returns the malformed credential versions left out of the
+// snapshot in the order CredHub listed them.
+func (s *snapshot) Skipped() []SkippedVersion {
+	return append(make([]SkippedVersion, 0, len(s.skipped)), s.skipped...)
 }
 
-func (s *state) clear() {
-	s.paths.Clear()
-	s.credentials.Clear()
-	s.deployments.Clear()
+// Deployments returns all deployments known to the BOSH director ordered by
+// name.
+func (s *snapshot) Deployments() Deployments {
+	return append(make(Deployments, 0, len(s.sortedDeployments)), s.sortedDeployments...)
 }
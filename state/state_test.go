@@ -0,0 +1,135 @@
+package state_test
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/bosh"
+	"github.com/cloudfoundry-community/carousel/credhub"
+	. "github.com/cloudfoundry-community/carousel/state"
+)
+
+var _ = Describe("State", func() {
+	var (
+		state       State
+		credentials []*credhub.Credential
+		variables   []*bosh.Variable
+	)
+
+	BeforeEach(func() {
+		state = NewState()
+		credentials = []*credhub.Credential{
+			password("/bosh/cf/admin_password", "v1", time.Now()),
+		}
+		variables = []*bosh.Variable{
+			{ID: "v1", Name: "/bosh/cf/admin_password", Deployment: "cf"},
+		}
+	})
+
+	Describe("Update", func() {
+		It("swaps in a new snapshot", func() {
+			Expect(state.Update(credentials, variables)).To(Succeed())
+			before := state.Snapshot()
+
+			credentials = append(credentials, password("/bosh/cf/other_password", "v2", time.Now()))
+			Expect(state.Update(credentials, variables)).To(Succeed())
+
+			Expect(before.Credentials()).To(HaveLen(1))
+			Expect(state.Credentials()).To(HaveLen(2))
+		})
+
+		It("does not fail on variables referencing unknown credentials", func() {
+			variables = append(variables, &bosh.Variable{
+				ID: "unknown", Name: "/bosh/cf/unknown", Deployment: "cf",
+			})
+			Expect(state.Update(credentials, variables)).To(Succeed())
+			Expect(state.Credentials()).To(HaveLen(1))
+			Expect(state.Deployments().Dangling()).To(HaveLen(1))
+		})
+
+		It("skips credential versions listed twice", func() {
+			credentials = append(credentials,
+				password("/bosh/cf/admin_password", "v1", time.Now()),
+				password("/bosh/cf/other_password", "v2", time.Now()))
+			Expect(state.Update(credentials, variables)).To(Succeed())
+			Expect(state.Credentials()).To(HaveLen(2))
+			Expect(state.Skipped()).To(Equal([]SkippedVersion{
+				{ID: "v1", Name: "/bosh/cf/admin_password", Reason: "listed twice"},
+			}))
+		})
+
+		It("skips credentials without creation time", func() {
+			credentials = append(credentials, password("/bosh/cf/other_password", "v2", time.Now()))
+			credentials[0].VersionCreatedAt = nil
+			Expect(state.Update(credentials, variables)).To(Succeed())
+			Expect(state.Credentials()).To(HaveLen(1))
+			Expect(state.Skipped()).To(Equal([]SkippedVersion{
+				{ID: "v1", Name: "/bosh/cf/admin_password", Reason: "no version_created_at"},
+			}))
+			Expect(state.Deployments().Dangling()).To(HaveLen(1))
+		})
+
+		It("can be read while updating", func() {
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				for i := 0; i < 50; i++ {
+					creds := append(credentials, password(fmt.Sprintf("/p%d", i), fmt.Sprintf("p%d", i), time.Now()))
+					Expect(state.Update(creds, variables)).To(Succeed())
+				}
+			}()
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				for i := 0; i < 50; i++ {
+					for _, c := range state.Credentials() {
+						Expect(c.Path.Versions).ToNot(BeEmpty())
+					}
+				}
+			}()
+			wg.Wait()
+		})
+	})
+
+	Describe("Subscribe", func() {
+		It("emits an event for each refresh", func() {
+			events, cancel := state.Subscribe()
+			defer cancel()
+
+			Expect(state.Update(credentials, variables)).To(Succeed())
+
+			var e Event
+			Eventually(events).Should(Receive(&e))
+			Expect(e.Err).ToNot(HaveOccurred())
+			Expect(e.Previous.Credentials()).To(BeEmpty())
+			Expect(e.Current.Credentials()).To(HaveLen(1))
+		})
+
+		It("only keeps the latest event for slow subscribers", func() {
+			events, cancel := state.Subscribe()
+			defer cancel()
+
+			Expect(state.Update(credentials, variables)).To(Succeed())
+			credentials = append(credentials, password("/bosh/cf/other_password", "v2", time.Now()))
+			Expect(state.Update(credentials, variables)).To(Succeed())
+
+			var e Event
+			Eventually(events).Should(Receive(&e))
+			Expect(e.Current.Credentials()).To(HaveLen(2))
+			Consistently(events).ShouldNot(Receive())
+		})
+
+		It("closes the channel on cancel", func() {
+			events, cancel := state.Subscribe()
+			cancel()
+			Expect(events).To(BeClosed())
+			Expect(state.Update(credentials, variables)).To(Succeed())
+		})
+	})
+})
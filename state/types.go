@@ -12,23 +12,44 @@ type Path struct {
 	Versions           Credentials              `json:"-"`
 	VariableDefinition *bosh.VariableDefinition `json:"variable_definition"`
 	Deployments        Deployments
+
+	paths map[string]*Path // all paths of the snapshot by name
 }
 
 type Deployment struct {
-	Versions Credentials `json:"-"`
-	Name     string      `json:"name"`
+	Versions Credentials         `json:"-"`
+	Name     string              `json:"name"`
+	Dangling []DanglingReference `json:"dangling,omitempty"`
+}
+
+// DanglingReference is a variable used by a deployment for which CredHub no
+// longer has the referenced credential version, a bosh deploy of the
+// deployment converges it.
+type DanglingReference struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// SkippedVersion is a credential version listed by CredHub which is left out
+// of the state as it is malformed, e.g. it has no creation time.
+type SkippedVersion struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
 }
 
 type Credential struct {
 	*credhub.Credential
-	Deployments  Deployments `json:"-"`
-	SignedBy     *Credential `json:"-"`
-	ReferencedBy Credentials `json:"-"`
-	References   Credentials `json:"-"`
-	Signs        Credentials `json:"-"`
-	Latest       bool        `json:"latest"`
-	Signing      *bool       `json:"signing,omitempty"`
-	Path         *Path       `json:"-"`
+	Deployments  Deployments  `json:"-"`
+	SignedBy     *Credential  `json:"-"`
+	Issuers      Credentials  `json:"-"`
+	IssuerStatus IssuerStatus `json:"issuer_status,omitempty"`
+	ReferencedBy Credentials  `json:"-"`
+	References   Credentials  `json:"-"`
+	Signs        Credentials  `json:"-"`
+	Latest       bool         `json:"latest"`
+	Signing      *bool        `json:"signing,omitempty"`
+	Path         *Path        `json:"-"`
 }
 
 type Credentials []*Credential
@@ -36,11 +57,15 @@ type Credentials []*Credential
 type Deployments []*Deployment
 
 func (d Deployments) String() string {
-	tmp := make([]string, 0, len(d))
+	return strings.Join(d.Names(), ", ")
+}
+
+func (d Deployments) Names() []string {
+	out := make([]string, 0, len(d))
 	for _, deployment := range d {
-		tmp = append(tmp, deployment.Name)
+		out = append(out, deployment.Name)
 	}
-	return strings.Join(tmp, ", ")
+	return out
 }
 
 func (d Deployments) Includes(this *Deployment) bool {
@@ -60,3 +85,20 @@ func (d Deployments) IncludesName(name string) bool {
 	}
 	return false
 }
+
+// Dangling returns the deployments which have dangling references.
+func (d Deployments) Dangling() Deployments {
+	return d.Select(func(deployment *Deployment) bool {
+		return len(deployment.Dangling) != 0
+	})
+}
+
+func (d Deployments) Select(fn func(*Deployment) bool) Deployments {
+	out := make(Deployments, 0)
+	for _, deployment := range d {
+		if fn(deployment) {
+			out = append(out, deployment)
+		}
+	}
+	return out
+}
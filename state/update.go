@@ -1,27 +1,24 @@
 package state
 
 import (
-	"fmt"
-
 	"github.com/cloudfoundry-community/carousel/bosh"
 	"github.com/cloudfoundry-community/carousel/credhub"
 )
 
-func (s *state) Update(credentials []*credhub.Credential, variables []*bosh.Variable) error {
-	s.clear()
-
+func (s *snapshot) update(credentials []*credhub.Credential, variables []*bosh.Variable,
+	previous *snapshot) error {
+	// A malformed version should not prevent working with the rest of
+	// CredHub, skip it and record why instead.
 	for _, cred := range credentials {
-		var path *Path
-		p, found := s.paths.Get(cred.Name)
-		if found {
-			path = p.(*Path)
-		} else {
-			path = &Path{
-				Name:        cred.Name,
-				Deployments: make(Deployments, 0),
-			}
-			s.paths.Put(cred.Name, path)
+		if cred.VersionCreatedAt == nil {
+			s.skip(cred, "no version_created_at")
+			continue
+		}
+		if _, found := s.credentials[cred.ID]; found {
+			s.skip(cred, "listed twice")
+			continue
 		}
+		path := s.getOrCreatePath(cred.Name)
 
 		c := Credential{
 			Credential:  cred,
@@ -30,22 +27,18 @@ func (s *state) Update(credentials []*credhub.Credential, variables []*bosh.Vari
 		}
 
 		path.Versions = append(path.Versions, &c)
-		s.credentials.Put(cred.ID, &c)
+		s.credentials[cred.ID] = &c
 	}
 
+	s.index()
+
 	// Lookup Ca for each cert
-	for _, cert := range s.Credentials(TypeFilter(credhub.Certificate)) {
-		authorityKeyID := cert.Certificate.AuthorityKeyId
-		if cert.SelfSigned {
-			continue
-		}
-		ca, found := s.getCredentialBySubjectKeyId(authorityKeyID)
-		if found {
-			ca.Signs = append(ca.Signs, cert)
-			cert.SignedBy = ca
-		}
+	certs := s.Credentials(TypeFilter(credhub.Certificate))
+	s.linkIssuers(certs, previous)
+
+	for _, cert := range certs {
 		for _, ca := range cert.Ca {
-			ca, found := s.getCredentialBySubjectKeyId(ca.SubjectKeyId)
+			ca, found := s.getCredentialByCertificate(ca)
 			if found {
 				cert.References = append(cert.References, ca)
 				ca.ReferencedBy = append(ca.ReferencedBy, cert)
@@ -78,30 +71,34 @@ func (s *state) Update(credentials []*credhub.Credential, variables []*bosh.Vari
 		}
 	})
 
+	// A deployment referencing a version which no longer exists should not
+	// prevent working with the rest of the director, record it instead. The
+	// deployment still uses the path, so it is pending a deploy of its latest
+	// version.
 	for _, variable := range variables {
 		d := s.getOrCreateDeployment(variable.Deployment)
-		credential, found := s.getCredential(variable.ID)
-		if !found {
-			return fmt.Errorf(`credential not found for bosh variable id: %s
-run bosh deploy for '%s' so the BOSH director can converge it's variable references`,
-				variable.ID, variable.Deployment)
-		}
-		credential.Deployments = append(credential.Deployments, d)
-		d.Versions = append(d.Versions, credential)
 
-		path, found := s.getPath(variable.Name)
-		if !found {
-			return fmt.Errorf(`path not found for bosh variable name: %s
-run bosh deploy for '%s' so the BOSH director can converge it's variable references`,
-				variable.Name, variable.Deployment)
+		path, pathFound := s.getPath(variable.Name)
+		if pathFound {
+			path.VariableDefinition = variable.Definition
+			if !path.Deployments.Includes(d) {
+				path.Deployments = append(path.Deployments, d)
+			}
 		}
 
-		path.VariableDefinition = variable.Definition
-
-		if !path.Deployments.Includes(d) {
-			path.Deployments = append(path.Deployments, d)
+		credential, found := s.getCredential(variable.ID)
+		if !found || !pathFound {
+			d.Dangling = append(d.Dangling, DanglingReference{ID: variable.ID, Name: variable.Name})
+			continue
 		}
+
+		credential.Deployments = append(credential.Deployments, d)
+		d.Versions = append(d.Versions, credential)
 	}
 
 	return nil
 }
+
+func (s *snapshot) skip(cred *credhub.Credential, reason string) {
+	s.skipped = append(s.skipped, SkippedVersion{ID: cred.ID, Name: cred.Name, Reason: reason})
+}
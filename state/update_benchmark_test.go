@@ -0,0 +1,48 @@
+package state_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/cloudfoundry-community/carousel/state"
+)
+
+// BenchmarkUpdate measures building the graph from scratch, which includes
+// verifying the signature of every certificate. 10000 credentials take about
+// 1.5s on a single core.
+func BenchmarkUpdate(b *testing.B) {
+	for _, size := range []int{1000, 10000, 100000} {
+		b.Run(fmt.Sprintf("credentials=%d", size), func(b *testing.B) {
+			credentials, variables := syntheticDataset(size)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := NewState().Update(credentials, variables); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkRefresh measures refreshing an existing state, as done by the
+// TUI and the rotate loop, reusing the signature checks of the previous
+// snapshot. 10000 credentials take about 0.1s on a single core.
+func BenchmarkRefresh(b *testing.B) {
+	for _, size := range []int{1000, 10000, 100000} {
+		b.Run(fmt.Sprintf("credentials=%d", size), func(b *testing.B) {
+			credentials, variables := syntheticDataset(size)
+			state := NewState()
+			if err := state.Update(credentials, variables); err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := state.Update(credentials, variables); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
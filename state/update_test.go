@@ -0,0 +1,184 @@
+package state_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/carousel/bosh"
+	"github.com/cloudfoundry-community/carousel/credhub"
+	. "github.com/cloudfoundry-community/carousel/state"
+)
+
+var _ = Describe("Update", func() {
+	var (
+		f           *certFactory
+		now         time.Time
+		state       State
+		credentials []*credhub.Credential
+		variables   []*bosh.Variable
+	)
+
+	byID := func(id string) *Credential {
+		cred, found := state.Credentials().Find(func(c *Credential) bool { return c.ID == id })
+		Expect(found).To(BeTrue(), "credential %s not found", id)
+		return cred
+	}
+
+	BeforeEach(func() {
+		f = newCertFactory()
+		now = time.Now()
+		state = NewState()
+		variables = []*bosh.Variable{}
+	})
+
+	JustBeforeEach(func() {
+		Expect(state.Update(credentials, variables)).To(Succeed())
+	})
+
+	Context("given a leaf signed by a ca", func() {
+		BeforeEach(func() {
+			ca := f.certificate("/ca", "ca-v1", true, nil, now)
+			leaf := f.certificate("/leaf", "leaf-v1", false, ca, now)
+			credentials = []*credhub.Credential{ca, leaf}
+		})
+
+		It("links the verified issuer", func() {
+			leaf := byID("leaf-v1")
+			Expect(leaf.IssuerStatus).To(Equal(IssuerVerified))
+			Expect(leaf.SignedBy).To(Equal(byID("ca-v1")))
+			Expect(leaf.References).To(Equal(Credentials{byID("ca-v1")}))
+			Expect(byID("ca-v1").Signs).To(Equal(Credentials{leaf}))
+
+			chain, complete := leaf.Chain()
+			Expect(chain).To(Equal(Credentials{leaf, byID("ca-v1")}))
+			Expect(complete).To(BeTrue())
+		})
+	})
+
+	Context("given a three level chain", func() {
+		BeforeEach(func() {
+			root := f.certificate("/root", "root-v1", true, nil, now)
+			intermediate := f.certificate("/intermediate", "intermediate-v1", true, root, now)
+			leaf := f.certificate("/leaf", "leaf-v1", false, intermediate, now)
+			credentials = []*credhub.Credential{root, intermediate, leaf}
+		})
+
+		It("builds the chain", func() {
+			chain, complete := byID("leaf-v1").Chain()
+			Expect(chain).To(Equal(Credentials{
+				byID("leaf-v1"), byID("intermediate-v1"), byID("root-v1"),
+			}))
+			Expect(complete).To(BeTrue())
+		})
+	})
+
+	Context("given ca versions reusing the same key", func() {
+		BeforeEach(func() {
+			key := newKey()
+			oldCa := f.certificateWithKey(key, "/ca", "ca-v1", true, nil, now.Add(-time.Hour))
+			newCa := f.certificateWithKey(key, "/ca", "ca-v2", true, nil, now)
+			newCa.Transitional = true
+			leaf := f.certificate("/leaf", "leaf-v1", false, oldCa, now)
+			credentials = []*credhub.Credential{oldCa, newCa, leaf}
+		})
+
+		It("prefers the non transitional version", func() {
+			leaf := byID("leaf-v1")
+			Expect(leaf.IssuerStatus).To(Equal(IssuerVerified))
+			Expect(leaf.Issuers).To(ConsistOf(byID("ca-v1"), byID("ca-v2")))
+			Expect(leaf.SignedBy).To(Equal(byID("ca-v1")))
+		})
+	})
+
+	Context("given a leaf signed by a cross-signed intermediate", func() {
+		BeforeEach(func() {
+			key := newKey()
+			rootA := f.certificate("/root-a", "root-a-v1", true, nil, now)
+			rootB := f.certificate("/root-b", "root-b-v1", true, nil, now)
+			intermediateA := f.certificateWithKey(key, "/intermediate", "intermediate-a", true, rootA, now)
+			intermediateB := f.certificateWithKey(key, "/intermediate-cross", "intermediate-b", true, rootB, now)
+			leaf := f.certificate("/leaf", "leaf-v1", false, intermediateA, now)
+			credentials = []*credhub.Credential{rootA, rootB, intermediateA, intermediateB, leaf}
+		})
+
+		It("flags the issuer as ambiguous", func() {
+			leaf := byID("leaf-v1")
+			Expect(leaf.IssuerStatus).To(Equal(IssuerAmbiguous))
+			Expect(leaf.SignedBy).To(BeNil())
+			Expect(leaf.Issuers).To(ConsistOf(byID("intermediate-a"), byID("intermediate-b")))
+			Expect(state.Credentials(SelfSignedFilter())).ToNot(ContainElement(leaf))
+		})
+
+		It("does not guess the next action", func() {
+			Expect(byID("leaf-v1").NextAction(RegenerationCriteria{
+				ExpiresBefore: now.Add(2 * 365 * 24 * time.Hour),
+			})).To(Equal(None))
+		})
+	})
+
+	Context("given a leaf with a matching authority key id but a forged signature", func() {
+		BeforeEach(func() {
+			ca := f.certificate("/ca", "ca-v1", true, nil, now)
+			impostor := f.certificate("/impostor", "impostor-v1", true, nil, now)
+			impostor.Certificate.SubjectKeyId = ca.Certificate.SubjectKeyId
+			leaf := f.certificate("/leaf", "leaf-v1", false, impostor, now)
+			credentials = []*credhub.Credential{ca, leaf}
+		})
+
+		It("flags the issuer as unverified", func() {
+			leaf := byID("leaf-v1")
+			Expect(leaf.IssuerStatus).To(Equal(IssuerUnverified))
+			Expect(leaf.SignedBy).To(BeNil())
+
+			_, complete := leaf.Chain()
+			Expect(complete).To(BeFalse())
+		})
+	})
+
+	Context("given a leaf signed by a ca outside of CredHub", func() {
+		BeforeEach(func() {
+			external := f.certificate("/external", "external-v1", true, nil, now)
+			leaf := f.certificate("/leaf", "leaf-v1", false, external, now)
+			credentials = []*credhub.Credential{leaf}
+		})
+
+		It("marks the issuer as external without flagging it", func() {
+			leaf := byID("leaf-v1")
+			Expect(leaf.IssuerStatus).To(Equal(IssuerExternal))
+			Expect(leaf.IssuerStatus.Flagged()).To(BeFalse())
+			Expect(state.Credentials(FlaggedIssuerFilter())).To(BeEmpty())
+		})
+	})
+
+	Context("given a deployment referencing a deleted version", func() {
+		BeforeEach(func() {
+			credentials = []*credhub.Credential{
+				f.certificate("/ca", "ca-v2", true, nil, now),
+			}
+			variables = []*bosh.Variable{
+				{ID: "ca-v1", Name: "/ca", Deployment: "broken"},
+				{ID: "ca-v2", Name: "/ca", Deployment: "healthy"},
+			}
+		})
+
+		It("records a dangling reference on the deployment", func() {
+			deployments := state.Deployments()
+			Expect(deployments).To(HaveLen(2))
+			Expect(deployments[0].Name).To(Equal("broken"))
+			Expect(deployments[0].Dangling).To(Equal([]DanglingReference{{ID: "ca-v1", Name: "/ca"}}))
+			Expect(deployments[1].Dangling).To(BeEmpty())
+			Expect(deployments.Dangling()).To(Equal(Deployments{deployments[0]}))
+		})
+
+		It("keeps linking the other deployments", func() {
+			Expect(byID("ca-v2").Deployments.String()).To(Equal("healthy"))
+		})
+
+		It("keeps the broken deployment pending a deploy of the latest version", func() {
+			Expect(byID("ca-v2").Path.Deployments.Names()).To(ConsistOf("broken", "healthy"))
+			Expect(byID("ca-v2").PendingDeploys().String()).To(Equal("broken"))
+		})
+	})
+})